@@ -0,0 +1,172 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"hash"
+	"hash/crc32"
+	"io"
+
+	"github.com/google/btree"
+)
+
+// Binary snapshot format:
+//
+//	magic   [4]byte "GCBS"
+//	version byte
+//	count   uvarint
+//	count times:
+//		key length uvarint, key
+//		value length uvarint, value encoded by ValueCodec
+//	crc     uint32 big-endian, CRC-32 (IEEE) of the payload from count to the last record
+var binaryMagic = [4]byte{'G', 'C', 'B', 'S'}
+
+const binaryVersion = 2
+
+// crcReader is a bufio.Reader updates a checksum by read bytes.
+type crcReader struct {
+	*bufio.Reader
+	h hash.Hash32
+}
+
+func (r *crcReader) Read(p []byte) (n int, err error) {
+	n, err = r.Reader.Read(p)
+	r.h.Write(p[:n])
+	return
+}
+
+func (r *crcReader) ReadByte() (c byte, err error) {
+	if c, err = r.Reader.ReadByte(); err == nil {
+		r.h.Write([]byte{c})
+	}
+	return
+}
+
+// SaveBinary writes a consistent snapshot of the cache to w in binary snapshot format.
+// Values are encoded by the ValueCodec given by WithValueCodec, GobCodec by default.
+func (ce *Cache) SaveBinary(w io.Writer) (err error) {
+	tr := ce.snapshot()
+	bw := bufio.NewWriter(w)
+	h := crc32.NewIEEE()
+	pw := io.MultiWriter(bw, h)
+	var buf [binary.MaxVarintLen64]byte
+	writeBytes := func(p []byte) {
+		if err != nil {
+			return
+		}
+		if _, err = pw.Write(buf[:binary.PutUvarint(buf[:], uint64(len(p)))]); err != nil {
+			return
+		}
+		_, err = pw.Write(p)
+	}
+	bw.Write(binaryMagic[:])
+	bw.WriteByte(binaryVersion)
+	if _, err = pw.Write(buf[:binary.PutUvarint(buf[:], uint64(tr.Len()))]); err != nil {
+		return
+	}
+	tr.Ascend(func(i btree.Item) bool {
+		im := i.(item)
+		var data []byte
+		if data, err = ce.codec.Encode(im.Val); err != nil {
+			return false
+		}
+		writeBytes([]byte(im.Key))
+		writeBytes(data)
+		return err == nil
+	})
+	if err != nil {
+		return
+	}
+	binary.BigEndian.PutUint32(buf[:4], h.Sum32())
+	if _, err = bw.Write(buf[:4]); err != nil {
+		return
+	}
+	err = bw.Flush()
+	return
+}
+
+// LoadBinary replaces the contents of the cache with the binary snapshot read from r.
+// It returns ErrInvalidSnapshot or ErrSnapshotVersion, if the header mismatches, and ErrCorruptSnapshot,
+// if the snapshot is truncated or its checksum mismatches. Only the current version is accepted.
+// The cache isn't changed, if the snapshot couldn't be decoded.
+func (ce *Cache) LoadBinary(r io.Reader) (err error) {
+	br := bufio.NewReader(r)
+	defer func() {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = ErrCorruptSnapshot
+		}
+	}()
+	var hdr [5]byte
+	if _, err = io.ReadFull(br, hdr[:]); err != nil {
+		return
+	}
+	if hdr[0] != binaryMagic[0] || hdr[1] != binaryMagic[1] || hdr[2] != binaryMagic[2] || hdr[3] != binaryMagic[3] {
+		err = ErrInvalidSnapshot
+		return
+	}
+	if hdr[4] != binaryVersion {
+		err = ErrSnapshotVersion
+		return
+	}
+	cr := &crcReader{Reader: br, h: crc32.NewIEEE()}
+	count, err := binary.ReadUvarint(cr)
+	if err != nil {
+		return
+	}
+	readBytes := func() (p []byte) {
+		if err != nil {
+			return
+		}
+		var n uint64
+		if n, err = binary.ReadUvarint(cr); err != nil {
+			return
+		}
+		if n > 1<<40 {
+			err = io.ErrUnexpectedEOF
+			return
+		}
+		// lengths of corrupt snapshots can be huge, so the buffer grows by read bytes.
+		var b bytes.Buffer
+		if _, err = io.CopyN(&b, cr, int64(n)); err != nil {
+			return
+		}
+		p = b.Bytes()
+		return
+	}
+	type record struct {
+		key, data []byte
+	}
+	var records []record
+	for ; count > 0; count-- {
+		key := readBytes()
+		data := readBytes()
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return
+		}
+		records = append(records, record{key: key, data: data})
+	}
+	var sum [4]byte
+	if _, err = io.ReadFull(br, sum[:]); err != nil {
+		return
+	}
+	if binary.BigEndian.Uint32(sum[:]) != cr.h.Sum32() {
+		err = ErrCorruptSnapshot
+		return
+	}
+	m := make(map[string]interface{}, len(records))
+	for _, rc := range records {
+		var val interface{}
+		if val, err = ce.codec.Decode(rc.data); err != nil {
+			return
+		}
+		if val != nil {
+			m[string(rc.key)] = val
+		}
+	}
+	ce.replace(m)
+	return
+}
@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"bytes"
+	"testing"
+)
+
+func binarySnapshot(t *testing.T) []byte {
+	ce := NewCache()
+	defer ce.Close()
+	ce.Set("a", 1)
+	ce.Set("b", "two")
+	ce.Set("c", []byte{3})
+	var buf bytes.Buffer
+	if err := ce.SaveBinary(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	data := binarySnapshot(t)
+	ce := NewCache()
+	defer ce.Close()
+	if err := ce.LoadBinary(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	if ce.Get("a") != 1 || ce.Get("b") != "two" || !bytes.Equal(ce.Get("c").([]byte), []byte{3}) {
+		t.Fatalf("got %v %v %v", ce.Get("a"), ce.Get("b"), ce.Get("c"))
+	}
+}
+
+func TestBinaryRejectsFlippedBytes(t *testing.T) {
+	data := binarySnapshot(t)
+	ce := NewCache()
+	defer ce.Close()
+	ce.Set("live", true)
+	for i := range data {
+		for _, mask := range []byte{0x01, 0x80, 0xff} {
+			bad := append([]byte(nil), data...)
+			bad[i] ^= mask
+			if err := ce.LoadBinary(bytes.NewReader(bad)); err == nil {
+				t.Fatalf("flipping byte %d by %#x was accepted", i, mask)
+			}
+		}
+	}
+	if ce.Get("live") != true || ce.Get("a") != nil {
+		t.Fatal("a rejected snapshot changed the cache")
+	}
+}
+
+func TestBinaryRejectsTruncated(t *testing.T) {
+	data := binarySnapshot(t)
+	ce := NewCache()
+	defer ce.Close()
+	for n := 0; n < len(data); n++ {
+		err := ce.LoadBinary(bytes.NewReader(data[:n]))
+		if err != ErrCorruptSnapshot {
+			t.Fatalf("truncated to %d bytes: got %v, want ErrCorruptSnapshot", n, err)
+		}
+	}
+}
+
+func TestBinaryRejectsOldVersion(t *testing.T) {
+	data := binarySnapshot(t)
+	data[4] = 1
+	ce := NewCache()
+	defer ce.Close()
+	if err := ce.LoadBinary(bytes.NewReader(data)); err != ErrSnapshotVersion {
+		t.Fatalf("got %v, want ErrSnapshotVersion", err)
+	}
+}
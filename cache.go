@@ -5,8 +5,11 @@ All methods of Cache struct are concurrency safe and operates cache atomically.
 package cache
 
 import (
+	"reflect"
 	"runtime"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/google/btree"
 )
@@ -14,6 +17,11 @@ import (
 var (
 	// DefaultDegree is default b-tree degree.
 	DefaultDegree = 4
+
+	// DefaultCommitBatchSize is default count of items the queue worker commits at once while holding the tree lock.
+	// Throughput of mixed readers and writers is flat over sizes from 16 to 256 by BenchmarkCommitBatchSize,
+	// and smaller sizes let readers through sooner.
+	DefaultCommitBatchSize = 64
 )
 
 // Cache struct is concurrency safe in-memory cache based on b-tree and hash-map indexing.
@@ -23,199 +31,939 @@ type Cache struct {
 	tr     *btree.BTree
 	trMu   sync.RWMutex
 	qu     map[string]item
+	cm     map[string]item
+	gen    uint64
+	wsSeq  uint64
+	seq    uint64
+	seqCh  chan struct{}
 	quMu   sync.RWMutex
 	quCh   chan struct{}
+	dirty  int32
+	cond   *sync.Cond
+	sigMu  sync.Mutex
+	sigSet bool
 	degree int
+
+	perKeyStats     bool
+	statItems       bool
+	strictNumeric   bool
+	codec           ValueCodec
+	onEvict         func(key string, val interface{})
+	maxItems        int
+	memTarget       uint64
+	memInterval     time.Duration
+	maxAge          time.Duration
+	commitBatchSize int
+	cmp             func(a, b string) int
+	collisionCheck  bool
+	errorHandler    func(err error)
+	tailBuffer      int
+	stats           *stats
+	disk            *diskTier
+	interceptor     Interceptor
+	opTimeout       time.Duration
+	breakUntil      int64
+	memoizeTTL      time.Duration
+	capThreshold    float64
+	capFn           func(used, limit int64)
+	capAbove        bool
+	ins             *btree.BTree
+	insSeq          uint64
+	sizeFn          func(val interface{}) int64
+	maxListLen      int
+	maxNamespaces   int
+	nsDelimiter     string
+	namespaces      map[string]int
+	nsQueued        map[string]int
+	txRetries       int
+	sortedMu        sync.RWMutex
+	sorted          map[string]sortedRef
+	sortedCount     int32
+	signal          SignalStrategy
+	sketch          *countMinSketch
+	tryTimeout      time.Duration
+	skipNoOpWrites  bool
+	descending      bool
+
+	hidden    int32
+	tokenSeq  uint64
+	lastWrite int64
+
+	expHeap expiryHeap
+	expMu   sync.Mutex
+	expWake chan struct{}
+
+	flights map[string]*flight
+	flMu    sync.Mutex
+
+	pins     map[string]struct{}
+	pinCount int32
+	pinMu    sync.RWMutex
+
+	watchers map[*watcher]struct{}
+	tailers  map[*tailer]struct{}
+	wMu      sync.RWMutex
 }
 
 // NewCache returns a new Cache has default degree.
-func NewCache() (ce *Cache) {
-	return NewCacheDegree(DefaultDegree)
+func NewCache(opts ...Option) (ce *Cache) {
+	return NewCacheDegree(DefaultDegree, opts...)
 }
 
 // NewCacheDegree returns a new Cache given degree.
-func NewCacheDegree(degree int) (ce *Cache) {
+func NewCacheDegree(degree int, opts ...Option) (ce *Cache) {
 	ce = &Cache{
-		done:   make(chan struct{}),
-		quCh:   make(chan struct{}, 1<<10),
-		degree: degree,
+		done:            make(chan struct{}),
+		quCh:            make(chan struct{}, 1<<10),
+		degree:          degree,
+		codec:           GobCodec{},
+		tryTimeout:      DefaultTryTimeout,
+		commitBatchSize: DefaultCommitBatchSize,
+		tailBuffer:      DefaultTailBuffer,
+		txRetries:       DefaultTransactionRetries,
+		seqCh:           make(chan struct{}),
+		expWake:         make(chan struct{}, 1),
+	}
+	for _, opt := range opts {
+		opt(ce)
+	}
+	switch ce.signal {
+	case SignalCoalesced:
+		ce.quCh = make(chan struct{}, 1)
+	case SignalCond:
+		ce.cond = sync.NewCond(&ce.sigMu)
 	}
 	ce.Flush()
 	go ce.queueWorker()
+	go ce.expiryWorker()
+	if ce.memTarget > 0 && ce.memInterval > 0 {
+		go ce.memoryPressureWorker()
+	}
+	if ce.maxAge > 0 {
+		go ce.maxAgeWorker()
+	}
 	return
 }
 
 // Flush flushes the cache.
 func (ce *Cache) Flush() {
-	ce.trMu.Lock()
-	ce.tr = btree.New(ce.degree)
+	ce.swap(btree.New(ce.degree))
+}
+
+// swap replaces the tree with tr and clears the queue, and returns the old tree and pending items.
+func (ce *Cache) swap(tr *btree.BTree) (oldTr *btree.BTree, pending map[string]item) {
 	ce.quMu.Lock()
-	ce.trMu.Unlock()
+	ce.trMu.Lock()
+	oldTr, pending = ce.tr, ce.qu
+	for key, im := range ce.cm {
+		if _, ok := pending[key]; !ok {
+			pending[key] = im
+		}
+	}
+	ce.tr = tr
+	ce.sortedMu.Lock()
+	ce.sorted = nil
+	atomic.StoreInt32(&ce.sortedCount, 0)
+	ce.sortedMu.Unlock()
+	ce.reindex()
+	ce.recountNamespaces()
 	ce.qu = make(map[string]item)
+	ce.cm = nil
+	ce.nsQueued = make(map[string]int)
+	ce.gen++
+	ce.tailGap()
+	ce.trMu.Unlock()
 	ce.quMu.Unlock()
+	if ce.disk != nil {
+		ce.disk.clear()
+	}
+	ce.expMu.Lock()
+	ce.expHeap = nil
+	ce.expMu.Unlock()
+	return
 }
 
 // Close closes the cache. It must be called if the cache will not use.
 func (ce *Cache) Close() {
-	ce.done <- struct{}{}
+	close(ce.done)
+	if ce.cond != nil {
+		ce.sigMu.Lock()
+		ce.cond.Broadcast()
+		ce.sigMu.Unlock()
+	}
 }
 
 func (ce *Cache) queueWorker() {
-	for {
-		select {
-		case <-ce.done:
-			return
-		case <-ce.quCh:
-		}
+	for ce.wait() {
 		for {
-			var im item
-			var found bool
 			ce.quMu.Lock()
-			for key := range ce.qu {
-				im = ce.qu[key]
-				found = true
-				delete(ce.qu, key)
-				break
-			}
-			if !found {
+			if len(ce.qu) == 0 {
 				ce.quMu.Unlock()
 				break
 			}
-			ce.trMu.Lock()
+			batch, gen := ce.qu, ce.gen
+			ce.qu = make(map[string]item, len(batch))
+			ce.cm = batch
+			ce.quMu.Unlock()
+			ce.commitBatch(batch, gen)
+			ce.quMu.Lock()
+			if ce.gen == gen {
+				ce.cm = nil
+				for _, im := range batch {
+					if im.Val != nil {
+						ce.queueNamespace(im.Key, -1)
+					}
+				}
+			}
+			ce.seq++
+			close(ce.seqCh)
+			ce.seqCh = make(chan struct{})
 			ce.quMu.Unlock()
+			runtime.Gosched()
+		}
+	}
+}
+
+// commitBatch commits the batch to the tree in chunks of commitBatchSize items, releasing trMu between chunks.
+// It abandons the rest of the batch, if the cache is flushed or replaced while committing.
+func (ce *Cache) commitBatch(batch map[string]item, gen uint64) {
+	events := make([]Event, 0, len(batch))
+	n := 0
+	ce.trMu.Lock()
+	for _, im := range batch {
+		if n == 0 && ce.gen != gen {
+			break
+		}
+		ce.commit(im)
+		events = append(events, im.event())
+		if ce.stats != nil {
+			atomic.AddUint64(&ce.stats.commits, 1)
+		}
+		n++
+		if ce.commitBatchSize > 0 && n >= ce.commitBatchSize {
+			ce.endChunk(events)
+			events, n = events[:0], 0
+			runtime.Gosched()
+			ce.trMu.Lock()
+		}
+	}
+	ce.endChunk(events)
+}
+
+// endChunk checks the capacity threshold, evicts and spills overflowed items after a chunk of commits, and notifies watchers.
+// It must be called while trMu is locked, and it unlocks trMu.
+func (ce *Cache) endChunk(events []Event) {
+	ce.checkCapacity()
+	evicted := ce.evictOverflow()
+	spilled := ce.spillOverflow()
+	ce.published(events, evicted)
+	ce.trMu.Unlock()
+	ce.spill(spilled)
+	ce.evicted(evicted)
+}
+
+// published notifies watchers for committed events and evicted items. It must be called while trMu is locked,
+// so events of all goroutines change the tree are published in the order of their changes.
+// Evicted placeholders aren't published, because they were published as deletes.
+func (ce *Cache) published(events []Event, evicted []item) {
+	for _, e := range events {
+		ce.publish(e)
+	}
+	for _, im := range evicted {
+		if !im.placeholder() {
+			ce.publish(Event{Key: im.Key})
+		}
+	}
+}
+
+// commit commits the item to the tree. It must be called while trMu is locked.
+func (ce *Cache) commit(im item) {
+	if im.wt > atomic.LoadInt64(&ce.lastWrite) {
+		atomic.StoreInt64(&ce.lastWrite, im.wt)
+	}
+	if ce.disk != nil {
+		ce.disk.invalidate(im.Key)
+	}
+	if im.Val == nil {
+		if r := ce.tr.Delete(im); r != nil {
+			ce.removed(r.(item))
+		}
+		return
+	}
+	if ce.ins != nil {
+		ce.index(&im)
+	}
+	r := ce.tr.ReplaceOrInsert(im)
+	if im.exp > 0 {
+		ce.scheduleExpiry(im)
+	}
+	if r == nil {
+		ce.countNamespace(im.Key, 1)
+	} else {
+		old := r.(item)
+		if old.st != nil && im.st != nil && old.st != im.st {
+			atomic.AddUint64(&im.st.hits, atomic.LoadUint64(&old.st.hits))
+		}
+		if ce.collisionCheck && old.Key != im.Key {
+			ce.error(&KeyCollisionError{Key: im.Key, Existing: old.Key})
+		}
+	}
+}
+
+// removed updates the indexes for the item deleted from the tree. It must be called while trMu is locked.
+func (ce *Cache) removed(im item) {
+	ce.unindex(im)
+	ce.countNamespace(im.Key, -1)
+	if atomic.LoadInt32(&ce.sortedCount) > 0 {
+		ce.unmapSorted(im.Key, im.ws)
+	}
+}
+
+// updated returns a new item of given key replaces the value of old, but keeps its expiry.
+// old is zero, if the key wasn't exist.
+func (ce *Cache) updated(key string, old item, val interface{}) (im item) {
+	im = ce.newItem(key, val)
+	im.exp = old.exp
+	return
+}
+
+// probe returns an item to search given key in the tree.
+func (ce *Cache) probe(key string) item {
+	return item{Key: key, cmp: ce.cmp}
+}
+
+// newItem returns a new item given key and value. A lookup key of SetSorted is mapped to its key in the tree.
+func (ce *Cache) newItem(key string, val interface{}) (im item) {
+	im = ce.newTreeItem(key, val)
+	if atomic.LoadInt32(&ce.sortedCount) > 0 {
+		im.Key = ce.sortedKey(key)
+	}
+	return
+}
+
+// newTreeItem is like newItem, but key is the key in the tree, so it isn't mapped.
+func (ce *Cache) newTreeItem(key string, val interface{}) (im item) {
+	now := time.Now().UnixNano()
+	im = item{Key: key, Val: val, cmp: ce.cmp, wt: now}
+	if ce.statItems && val != nil {
+		im.st = &itemStat{atime: now}
+	}
+	if atomic.LoadInt32(&ce.pinCount) > 0 {
+		im.pin = ce.isPinned(key)
+	}
+	return
+}
+
+// sameValue reports whether a and b are comparable and equal.
+func sameValue(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if ta := reflect.TypeOf(a); ta != reflect.TypeOf(b) || !ta.Comparable() {
+		return false
+	}
+	return a == b
+}
+
+// error reports err to the error handler given by WithErrorHandler.
+func (ce *Cache) error(err error) {
+	if ce.errorHandler != nil {
+		ce.errorHandler(err)
+	}
+}
+
+// enqueue puts the item into the queue with a new write sequence. It must be called while quMu is locked.
+func (ce *Cache) enqueue(im item) {
+	ce.wsSeq++
+	im.ws = ce.wsSeq
+	if atomic.LoadInt32(&ce.sortedCount) > 0 {
+		ce.trackSorted(im)
+	}
+	if ce.ins != nil && im.Val != nil {
+		if p, ok := ce.queued(im.Key); ok && p.Val != nil && p.ins != 0 {
+			im.ins = p.ins
+		} else if im.ins == 0 {
+			im.ins = atomic.AddUint64(&ce.insSeq, 1)
+		}
+	}
+	if p, ok := ce.qu[im.Key]; ok && p.Val != nil {
+		ce.queueNamespace(p.Key, -1)
+	}
+	if im.Val != nil {
+		ce.queueNamespace(im.Key, 1)
+	}
+	ce.qu[im.Key] = im
+	if ce.stats != nil {
+		atomic.AddUint64(&ce.stats.writes, 1)
+	}
+}
+
+// queued returns the item of given key from the queue or the committing batch. It must be called while quMu is locked.
+func (ce *Cache) queued(key string) (im item, ok bool) {
+	if im, ok = ce.qu[key]; ok {
+		return
+	}
+	im, ok = ce.cm[key]
+	return
+}
+
+// lookup returns the latest visible item of given key from the queue or the tree. It must be called while quMu is locked.
+func (ce *Cache) lookup(key string) (im item, ok bool) {
+	if im, ok = ce.find(key); ok {
+		ok = im.visible()
+	}
+	return
+}
+
+// present is like lookup, but it returns also reservations, so writes create missing keys don't overwrite them.
+// It must be called while quMu is locked.
+func (ce *Cache) present(key string) (im item, ok bool) {
+	if im, ok = ce.find(key); ok {
+		ok = im.visible() || isReservation(im.Val)
+	}
+	return
+}
+
+// find is like lookup, but it returns also reservations. It must be called while quMu is locked.
+func (ce *Cache) find(key string) (im item, ok bool) {
+	im, ok, _ = ce.findUntil(key, time.Time{})
+	return
+}
+
+// findUntil is like find, but it gives up if trMu couldn't be read-locked until the deadline. locked is false, if it gave up.
+// If the deadline is zero, it never gives up.
+func (ce *Cache) findUntil(key string, deadline time.Time) (im item, ok, locked bool) {
+	locked = true
+	if atomic.LoadInt32(&ce.sortedCount) > 0 {
+		key = ce.sortedKey(key)
+	}
+	if im, ok = ce.queued(key); ok {
+		ok = im.Val != nil && !im.expired()
+		return
+	}
+	if deadline.IsZero() {
+		ce.trMu.RLock()
+	} else if !lockUntil(deadline, ce.trMu.TryRLock) {
+		locked = false
+		return
+	}
+	r := ce.tr.Get(ce.probe(key))
+	ce.trMu.RUnlock()
+	if r == nil {
+		return
+	}
+	im = r.(item)
+	ok = !im.expired()
+	return
+}
+
+// lockQueue locks quMu. It gives up and returns false, if quMu couldn't be locked until the deadline.
+// If the deadline is zero, it never gives up.
+func (ce *Cache) lockQueue(deadline time.Time) bool {
+	if deadline.IsZero() {
+		ce.quMu.Lock()
+		return true
+	}
+	return lockUntil(deadline, ce.quMu.TryLock)
+}
+
+// snapshot returns a lazy copy of the tree includes pending items in the queue.
+// The copy can be read without holding any lock.
+func (ce *Cache) snapshot() (tr *btree.BTree) {
+	ce.quMu.RLock()
+	tr = ce.snapshotLocked()
+	ce.quMu.RUnlock()
+	if atomic.LoadInt32(&ce.hidden) != 0 {
+		var hidden []btree.Item
+		tr.Ascend(func(i btree.Item) bool {
+			if !i.(item).visible() {
+				hidden = append(hidden, i)
+			}
+			return true
+		})
+		for _, i := range hidden {
+			tr.Delete(i)
+		}
+	}
+	return
+}
+
+// snapshotLocked is like snapshot, but it doesn't prune hidden items. It must be called while quMu is locked.
+func (ce *Cache) snapshotLocked() (tr *btree.BTree) {
+	ce.trMu.Lock()
+	tr = ce.tr.Clone()
+	ce.trMu.Unlock()
+	for _, qu := range []map[string]item{ce.cm, ce.qu} {
+		for _, im := range qu {
 			if im.Val != nil {
-				ce.tr.ReplaceOrInsert(im)
+				tr.ReplaceOrInsert(im)
 			} else {
-				ce.tr.Delete(im)
+				tr.Delete(im)
 			}
-			ce.trMu.Unlock()
-			runtime.Gosched()
 		}
 	}
+	return
 }
 
 // Get returns the value of given key. It returns nil, if the key wasn't exist.
 func (ce *Cache) Get(key string) (val interface{}) {
+	if ce.interceptor != nil {
+		return ce.interceptor(OpGet, key, func() interface{} {
+			return ce.get(key)
+		})
+	}
+	return ce.get(key)
+}
+
+func (ce *Cache) get(key string) (val interface{}) {
+	if atomic.LoadInt32(&ce.sortedCount) > 0 {
+		key = ce.sortedKey(key)
+	}
+	if ce.sketch != nil {
+		ce.sketch.add(key)
+	}
 	ce.quMu.RLock()
-	if im, ok := ce.qu[key]; ok {
+	if im, ok := ce.queued(key); ok {
 		ce.quMu.RUnlock()
+		if !im.visible() {
+			return
+		}
 		val = im.Val
+		im.hit()
 		return
 	}
 	ce.quMu.RUnlock()
 	ce.trMu.RLock()
-	r := ce.tr.Get(item{Key: key})
+	r := ce.tr.Get(ce.probe(key))
 	if r == nil {
 		ce.trMu.RUnlock()
+		if ce.disk != nil {
+			val = ce.promote(key)
+		}
 		return
 	}
 	ce.trMu.RUnlock()
-	val = r.(item).Val
+	im := r.(item)
+	if !im.visible() {
+		return
+	}
+	val = im.Val
+	im.hit()
 	return
 }
 
 // Set sets the value of given key. It deletes the key, if the val is nil.
+// If the write is rejected by WithMaxNamespaces, the error is reported to the error handler. See SetErr.
 func (ce *Cache) Set(key string, val interface{}) {
+	if err := ce.SetErr(key, val); err != nil {
+		ce.error(err)
+	}
+}
+
+// SetErr is like Set, but it returns ErrTooManyNamespaces, if the key is rejected by WithMaxNamespaces.
+func (ce *Cache) SetErr(key string, val interface{}) (err error) {
+	if ce.interceptor != nil {
+		ce.interceptor(OpSet, key, func() interface{} {
+			err = ce.set(key, val)
+			return nil
+		})
+		return
+	}
+	return ce.set(key, val)
+}
+
+func (ce *Cache) set(key string, val interface{}) (err error) {
 	ce.quMu.Lock()
-	ce.qu[key] = item{Key: key, Val: val}
-	ce.quMu.Unlock()
-	select {
-	case ce.quCh <- struct{}{}:
-	default:
+	if val != nil {
+		if err = ce.checkNamespace(key); err != nil {
+			ce.quMu.Unlock()
+			return
+		}
 	}
+	ce.enqueue(ce.newItem(key, val))
+	ce.quMu.Unlock()
+	ce.notify()
+	return
 }
 
 // Del deletes the key.
 func (ce *Cache) Del(key string) {
-	ce.Set(key, nil)
+	if ce.interceptor != nil {
+		ce.interceptor(OpDel, key, func() interface{} {
+			ce.set(key, nil)
+			return nil
+		})
+		return
+	}
+	ce.set(key, nil)
 }
 
 // GetOrSet returns the existing value for the key if present. Otherwise, it sets and returns the given value.
-// If the key was exist, the found is true.
+// If the key was exist, the found is true. If the key is reserved, it returns nil and found true without writing.
+// If the key is rejected by WithMaxNamespaces, it returns nil and found false without writing, and the error is reported
+// to the error handler.
 func (ce *Cache) GetOrSet(key string, newVal interface{}) (oldVal interface{}, found bool) {
-	found = true
-	ce.quMu.Lock()
-	if im, ok := ce.qu[key]; ok {
+	oldVal, found, err := ce.getOrSet(key, newVal, time.Time{})
+	if err != nil {
+		ce.error(err)
+	}
+	return
+}
+
+func (ce *Cache) getOrSet(key string, newVal interface{}, deadline time.Time) (oldVal interface{}, found bool, err error) {
+	if !ce.lockQueue(deadline) {
+		err = ErrTimeout
+		return
+	}
+	im, ok, locked := ce.findUntil(key, deadline)
+	if !locked {
 		ce.quMu.Unlock()
-		oldVal = im.Val
+		err = ErrTimeout
 		return
 	}
-	ce.trMu.RLock()
-	r := ce.tr.Get(item{Key: key})
-	if r == nil {
-		ce.qu[key] = item{Key: key, Val: newVal}
+	if ok && im.visible() {
 		ce.quMu.Unlock()
-		ce.trMu.RUnlock()
-		select {
-		case ce.quCh <- struct{}{}:
-		default:
-		}
-		oldVal = newVal
-		found = false
+		oldVal, found = im.Val, true
+		return
+	}
+	if ok && isReservation(im.Val) {
+		ce.quMu.Unlock()
+		found = true
 		return
 	}
+	if err = ce.checkNamespace(key); err != nil {
+		ce.quMu.Unlock()
+		return
+	}
+	ce.enqueue(ce.newItem(key, newVal))
 	ce.quMu.Unlock()
-	ce.trMu.RUnlock()
-	oldVal = r.(item).Val
+	ce.notify()
+	oldVal = newVal
 	return
 }
 
 // GetAndSet returns the replaced value for the key if present. Otherwise, returns nil.
-// Value replaces by f.
+// Value replaces by f. If WithSkipNoOpWrites option is given, nothing is enqueued when f returns the same value.
 func (ce *Cache) GetAndSet(key string, f func(interface{}) interface{}) (newVal interface{}) {
+	newVal, _ = ce.getAndSet(key, f, time.Time{})
+	return
+}
+
+func (ce *Cache) getAndSet(key string, f func(interface{}) interface{}, deadline time.Time) (newVal interface{}, err error) {
+	if !ce.lockQueue(deadline) {
+		err = ErrTimeout
+		return
+	}
+	im, ok, locked := ce.findUntil(key, deadline)
+	if !locked {
+		ce.quMu.Unlock()
+		err = ErrTimeout
+		return
+	}
+	if !ok || !im.visible() {
+		ce.quMu.Unlock()
+		return
+	}
+	newVal = f(im.Val)
+	if ce.skipNoOpWrites && sameValue(newVal, im.Val) {
+		ce.quMu.Unlock()
+		return
+	}
+	ce.enqueue(ce.updated(key, im, newVal))
+	ce.quMu.Unlock()
+	ce.notify()
+	return
+}
+
+// GetAndMaybeSet is like GetAndSet, but f decides whether the value is written by returning write.
+// If write is false, the entry is left untouched and nothing is enqueued.
+// It returns the new value if written, the old value otherwise. If the key wasn't exist, it returns nil.
+// If WithSkipNoOpWrites option is given, nothing is enqueued when f returns the same value.
+func (ce *Cache) GetAndMaybeSet(key string, f func(oldVal interface{}) (newVal interface{}, write bool)) (val interface{}) {
 	ce.quMu.Lock()
-	if im, ok := ce.qu[key]; ok {
-		newVal = f(im.Val)
-		ce.qu[key] = item{Key: key, Val: newVal}
+	im, ok := ce.lookup(key)
+	if !ok {
 		ce.quMu.Unlock()
-		select {
-		case ce.quCh <- struct{}{}:
-		default:
-		}
 		return
 	}
-	ce.trMu.RLock()
-	r := ce.tr.Get(item{Key: key})
-	if r == nil {
+	val = im.Val
+	newVal, write := f(im.Val)
+	if !write || (ce.skipNoOpWrites && sameValue(newVal, im.Val)) {
 		ce.quMu.Unlock()
-		ce.trMu.RUnlock()
 		return
 	}
-	newVal = f(r.(item).Val)
-	ce.qu[key] = item{Key: key, Val: newVal}
+	val = newVal
+	ce.enqueue(ce.updated(key, im, newVal))
 	ce.quMu.Unlock()
-	ce.trMu.RUnlock()
-	select {
-	case ce.quCh <- struct{}{}:
-	default:
+	ce.notify()
+	return
+}
+
+// SetIf sets the value of given key only if pred returns true for the current value, and returns whether it was set.
+// pred is called atomically with the write. exists is false, if the key wasn't exist.
+// If the key is reserved, it returns false without calling pred. If the key is rejected by WithMaxNamespaces,
+// it returns false and the error is reported to the error handler.
+func (ce *Cache) SetIf(key string, newVal interface{}, pred func(current interface{}, exists bool) bool) (ok bool) {
+	ce.quMu.Lock()
+	im, exists := ce.present(key)
+	if isReservation(im.Val) || !pred(im.Val, exists) {
+		ce.quMu.Unlock()
+		return
+	}
+	if !exists && newVal != nil {
+		if err := ce.checkNamespace(key); err != nil {
+			ce.quMu.Unlock()
+			ce.error(err)
+			return
+		}
 	}
+	ce.enqueue(ce.newItem(key, newVal))
+	ce.quMu.Unlock()
+	ce.notify()
+	ok = true
 	return
 }
 
 // Inc increases and the value of given key if the value is int or int64, and after returns new value.
-// Otherwise returns old value.
+// Otherwise returns old value. If WithStrictNumeric option is given, it panics with ErrNotNumeric for non-numeric values.
 func (ce *Cache) Inc(key string, x int64) (val interface{}) {
-	return ce.GetAndSet(key, func(val2 interface{}) interface{} {
+	val, err := ce.IncErr(key, x)
+	if err != nil && ce.strictNumeric {
+		panic(err)
+	}
+	return
+}
+
+// Dec decreases and the value of given key if the value is int or int64, and after returns new value.
+// Otherwise returns old value. If WithStrictNumeric option is given, it panics with ErrNotNumeric for non-numeric values.
+func (ce *Cache) Dec(key string, x int64) (val interface{}) {
+	val, err := ce.DecErr(key, x)
+	if err != nil && ce.strictNumeric {
+		panic(err)
+	}
+	return
+}
+
+// IncFloat increases and the value of given key if the value is float32 or float64, and after returns new value.
+// Otherwise returns old value. If WithStrictNumeric option is given, it panics with ErrNotNumeric for non-numeric values.
+func (ce *Cache) IncFloat(key string, x float64) (val interface{}) {
+	val, err := ce.IncFloatErr(key, x)
+	if err != nil && ce.strictNumeric {
+		panic(err)
+	}
+	return
+}
+
+// IncErr is like Inc, but it returns ErrNotNumeric with old value instead of silently leaving non-numeric values.
+func (ce *Cache) IncErr(key string, x int64) (val interface{}, err error) {
+	return ce.updateNumeric(key, func(val2 interface{}) interface{} {
 		switch val2.(type) {
 		case int:
 			return val2.(int) + int(x)
 		case int64:
 			return val2.(int64) + int64(x)
 		}
-		return val2
+		return nil
 	})
 }
 
-// Dec decreases and the value of given key if the value is int or int64, and after returns new value.
-// Otherwise returns old value.
-func (ce *Cache) Dec(key string, x int64) (val interface{}) {
-	return ce.GetAndSet(key, func(val2 interface{}) interface{} {
+// DecErr is like Dec, but it returns ErrNotNumeric with old value instead of silently leaving non-numeric values.
+func (ce *Cache) DecErr(key string, x int64) (val interface{}, err error) {
+	return ce.updateNumeric(key, func(val2 interface{}) interface{} {
 		switch val2.(type) {
 		case int:
 			return val2.(int) - int(x)
 		case int64:
 			return val2.(int64) - int64(x)
 		}
-		return val2
+		return nil
+	})
+}
+
+// IncFloatErr is like IncFloat, but it returns ErrNotNumeric with old value instead of silently leaving non-numeric values.
+func (ce *Cache) IncFloatErr(key string, x float64) (val interface{}, err error) {
+	return ce.updateNumeric(key, func(val2 interface{}) interface{} {
+		switch val2.(type) {
+		case float32:
+			return val2.(float32) + float32(x)
+		case float64:
+			return val2.(float64) + x
+		}
+		return nil
+	})
+}
+
+// ReadAndReset returns the value of given key and resets it to zero atomically, if the value is int, int64, float32 or float64.
+// Non-numeric values are returned and deleted. ok is false, if the key wasn't exist.
+func (ce *Cache) ReadAndReset(key string) (val interface{}, ok bool) {
+	ce.GetAndSet(key, func(oldVal interface{}) interface{} {
+		val, ok = oldVal, true
+		switch oldVal.(type) {
+		case int:
+			return int(0)
+		case int64:
+			return int64(0)
+		case float32:
+			return float32(0)
+		case float64:
+			return float64(0)
+		}
+		return nil
 	})
+	return
+}
+
+// IncMulti increases the values of the keys by deltas atomically in a single critical section, and returns new values.
+// Missing keys are set to their deltas as int64. Values of int type stay int. Keys have non-numeric values are left
+// untouched and omitted from the result. Missing keys rejected by WithMaxNamespaces are omitted too, and the error is
+// reported to the error handler.
+func (ce *Cache) IncMulti(deltas map[string]int64) (vals map[string]int64) {
+	vals = make(map[string]int64, len(deltas))
+	var err error
+	ce.quMu.Lock()
+	for key, x := range deltas {
+		var newVal interface{}
+		im, ok := ce.present(key)
+		if !ok {
+			if err2 := ce.checkNamespace(key); err2 != nil {
+				err = err2
+				continue
+			}
+			newVal = x
+		} else {
+			switch val := im.Val.(type) {
+			case int:
+				newVal = val + int(x)
+			case int64:
+				newVal = val + x
+			default:
+				continue
+			}
+		}
+		switch val := newVal.(type) {
+		case int:
+			vals[key] = int64(val)
+		case int64:
+			vals[key] = val
+		}
+		ce.enqueue(ce.updated(key, im, newVal))
+	}
+	ce.quMu.Unlock()
+	ce.notify()
+	if err != nil {
+		ce.error(err)
+	}
+	return
+}
+
+// IncDetailed increases the value of given key by x atomically, and returns new value and whether the key was created.
+// A missing key is set to x as int64 and reported as created in the same critical section. Values of int type stay int.
+// A non-numeric value is left untouched and IncDetailed returns 0, or panics with ErrNotNumeric if WithStrictNumeric option is given.
+// A missing key rejected by WithMaxNamespaces isn't created, IncDetailed returns 0 and the error is reported to the error handler.
+func (ce *Cache) IncDetailed(key string, x int64) (newVal int64, created bool) {
+	ce.quMu.Lock()
+	im, ok := ce.present(key)
+	var val interface{}
+	if !ok {
+		if err := ce.checkNamespace(key); err != nil {
+			ce.quMu.Unlock()
+			ce.error(err)
+			return
+		}
+		val, newVal, created = x, x, true
+	} else {
+		switch v := im.Val.(type) {
+		case int:
+			val, newVal = v+int(x), int64(v+int(x))
+		case int64:
+			val, newVal = v+x, v+x
+		case reservation:
+			ce.quMu.Unlock()
+			return
+		default:
+			ce.quMu.Unlock()
+			if ce.strictNumeric {
+				panic(ErrNotNumeric)
+			}
+			return
+		}
+	}
+	ce.enqueue(ce.updated(key, im, val))
+	ce.quMu.Unlock()
+	ce.notify()
+	return
+}
+
+// updateNumeric replaces the value of given key by f. f must return nil for non-numeric values.
+func (ce *Cache) updateNumeric(key string, f func(interface{}) interface{}) (val interface{}, err error) {
+	val = ce.GetAndMaybeSet(key, func(oldVal interface{}) (interface{}, bool) {
+		newVal := f(oldVal)
+		if newVal == nil {
+			err = ErrNotNumeric
+			return nil, false
+		}
+		return newVal, true
+	})
+	return
+}
+
+// MergeMap adds the entries of delta into the map[string]int value of given key atomically.
+// It creates the map if the key wasn't exist. The stored map is copied instead of modified,
+// so maps returned by Get are never changed. If the value isn't map[string]int, it is left untouched.
+// If the missing key is rejected by WithMaxNamespaces, the error is reported to the error handler.
+func (ce *Cache) MergeMap(key string, delta map[string]int) {
+	ce.quMu.Lock()
+	im, ok := ce.present(key)
+	var m map[string]int
+	if ok {
+		old, isMap := im.Val.(map[string]int)
+		if !isMap {
+			ce.quMu.Unlock()
+			return
+		}
+		m = make(map[string]int, len(old)+len(delta))
+		for k, v := range old {
+			m[k] = v
+		}
+	} else {
+		if err := ce.checkNamespace(key); err != nil {
+			ce.quMu.Unlock()
+			ce.error(err)
+			return
+		}
+		m = make(map[string]int, len(delta))
+	}
+	for k, v := range delta {
+		m[k] += v
+	}
+	ce.enqueue(ce.updated(key, im, m))
+	ce.quMu.Unlock()
+	ce.notify()
+}
+
+// Upsert sets the value of given key with version, if the key wasn't exist or version is greater than the stored version.
+// It returns whether it was written. The version compare and the write are atomic. A reserved key is never written.
+// Writes by other methods reset the stored version to 0. If the missing key is rejected by WithMaxNamespaces,
+// it returns false and the error is reported to the error handler.
+func (ce *Cache) Upsert(key string, val interface{}, version uint64) (ok bool) {
+	ce.quMu.Lock()
+	im, exists := ce.present(key)
+	if exists && (isReservation(im.Val) || version <= im.ver) {
+		ce.quMu.Unlock()
+		return
+	}
+	if !exists && val != nil {
+		if err := ce.checkNamespace(key); err != nil {
+			ce.quMu.Unlock()
+			ce.error(err)
+			return
+		}
+	}
+	im = ce.newItem(key, val)
+	im.ver = version
+	ce.enqueue(im)
+	ce.quMu.Unlock()
+	ce.notify()
+	ok = true
+	return
 }
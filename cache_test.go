@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"strconv"
+	"testing"
+)
+
+func BenchmarkCommitBatchSize(b *testing.B) {
+	const keys = 1 << 12
+	names := make([]string, keys)
+	for i := range names {
+		names[i] = strconv.Itoa(i)
+	}
+	for _, size := range []int{0, 16, 64, 256, 1024} {
+		b.Run("size="+strconv.Itoa(size), func(b *testing.B) {
+			ce := NewCache(WithCommitBatchSize(size))
+			defer ce.Close()
+			for i, key := range names {
+				ce.Set(key, i)
+			}
+			ce.Sync()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					key := names[i&(keys-1)]
+					// one write per three reads of GetOrSet, which takes the tree lock.
+					if i&3 == 0 {
+						ce.Set(key, i)
+					} else {
+						ce.GetOrSet(key, i)
+					}
+					i++
+				}
+			})
+			b.StopTimer()
+			ce.Sync()
+		})
+	}
+}
@@ -0,0 +1,270 @@
+/*
+Package cachetest offers helpers to validate cache.Cache under custom workloads and configurations.
+*/
+package cachetest
+
+import (
+	"fmt"
+	"math/bits"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+
+	cache "github.com/orkunkaraduman/go-cache"
+)
+
+// StressConfig is the workload of StressTest.
+type StressConfig struct {
+	// Readers, Writers and Deleters are counts of goroutines calling Get, Set and Del.
+	// At least one writer runs, even if Writers isn't positive.
+	Readers, Writers, Deleters int
+
+	// Keys is count of distinct keys. DefaultStressKeys is used, if it isn't positive.
+	Keys int
+
+	// Duration is the time of the workload. DefaultStressDuration is used, if it isn't positive.
+	Duration time.Duration
+
+	// Seed is the seed of random key selection. Same seeds give same key sequences for every goroutine.
+	Seed int64
+}
+
+var (
+	// DefaultStressKeys is default count of distinct keys of StressTest.
+	DefaultStressKeys = 1 << 10
+
+	// DefaultStressDuration is default duration of StressTest.
+	DefaultStressDuration = time.Second
+)
+
+// Latency is the latency distribution of an operation. Percentiles are upper bounds of power of two buckets,
+// so they are accurate up to a factor of two.
+type Latency struct {
+	P50, P90, P99, Max time.Duration
+}
+
+// StressResult is the result of StressTest.
+type StressResult struct {
+	// Reads, Writes and Deletes are counts of completed operations.
+	Reads, Writes, Deletes uint64
+
+	// Duration is the measured time of the workload.
+	Duration time.Duration
+
+	// ReadLatency, WriteLatency and DeleteLatency are latency distributions of operations.
+	ReadLatency, WriteLatency, DeleteLatency Latency
+
+	// Inconsistencies are descriptions of detected inconsistencies. It is empty, if the cache behaved correctly.
+	Inconsistencies []string
+}
+
+// Throughput returns count of operations per second.
+func (r StressResult) Throughput() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(r.Reads+r.Writes+r.Deletes) / r.Duration.Seconds()
+}
+
+// histogram is a latency histogram of power of two buckets in nanoseconds.
+type histogram struct {
+	buckets [64]uint64
+	count   uint64
+	max     time.Duration
+}
+
+func (h *histogram) add(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	h.buckets[bits.Len64(uint64(d))]++
+	h.count++
+	if d > h.max {
+		h.max = d
+	}
+}
+
+func (h *histogram) merge(o *histogram) {
+	for i, n := range o.buckets {
+		h.buckets[i] += n
+	}
+	h.count += o.count
+	if o.max > h.max {
+		h.max = o.max
+	}
+}
+
+func (h *histogram) percentile(p float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	rank := uint64(p * float64(h.count))
+	var n uint64
+	for i, c := range h.buckets {
+		n += c
+		if n > rank {
+			if i == 0 {
+				return 0
+			}
+			d := time.Duration(1)<<uint(i) - 1
+			if d > h.max {
+				d = h.max
+			}
+			return d
+		}
+	}
+	return h.max
+}
+
+func (h *histogram) latency() Latency {
+	return Latency{
+		P50: h.percentile(0.50),
+		P90: h.percentile(0.90),
+		P99: h.percentile(0.99),
+		Max: h.max,
+	}
+}
+
+// stresser is the state of a goroutine of StressTest.
+type stresser struct {
+	rnd   *rand.Rand
+	hist  histogram
+	ops   uint64
+	last  map[string]int64
+	fails []string
+}
+
+// StressTest runs readers, writers and deleters on ce concurrently for the duration, and returns the result.
+// Every key is written by a single writer with increasing int64 values, so readers verify that values of every key
+// never go back, and the final values are verified against the last writes after the workload. Without deleters,
+// a missing final value is reported as a lost write, so ce shouldn't evict or expire keys then. ce should be
+// dedicated to the test, its keys are overwritten and deleted. It is safe to run under the race detector.
+func StressTest(ce *cache.Cache, cfg StressConfig) (result StressResult) {
+	if cfg.Keys <= 0 {
+		cfg.Keys = DefaultStressKeys
+	}
+	if cfg.Duration <= 0 {
+		cfg.Duration = DefaultStressDuration
+	}
+	if cfg.Writers <= 0 {
+		cfg.Writers = 1
+	}
+	keys := make([]string, cfg.Keys)
+	for i := range keys {
+		keys[i] = "stress:" + strconv.Itoa(i)
+	}
+	newStressers := func(n int, base int64) []*stresser {
+		ss := make([]*stresser, n)
+		for i := range ss {
+			ss[i] = &stresser{
+				rnd:  rand.New(rand.NewSource(cfg.Seed + base + int64(i))),
+				last: make(map[string]int64),
+			}
+		}
+		return ss
+	}
+	readers := newStressers(cfg.Readers, 0)
+	writers := newStressers(cfg.Writers, 1<<20)
+	deleters := newStressers(cfg.Deleters, 2<<20)
+
+	stop := make(chan struct{})
+	stopped := func() bool {
+		select {
+		case <-stop:
+			return true
+		default:
+			return false
+		}
+	}
+	var wg sync.WaitGroup
+	run := func(s *stresser, op func(s *stresser)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !stopped() {
+				start := time.Now()
+				op(s)
+				s.hist.add(time.Since(start))
+				s.ops++
+			}
+		}()
+	}
+	for _, s := range readers {
+		run(s, func(s *stresser) {
+			key := keys[s.rnd.Intn(len(keys))]
+			val := ce.Get(key)
+			if val == nil {
+				return
+			}
+			v, ok := val.(int64)
+			if !ok {
+				s.fails = append(s.fails, fmt.Sprintf("key %q has unexpected value %#v", key, val))
+				return
+			}
+			if last, ok := s.last[key]; ok && v < last {
+				s.fails = append(s.fails, fmt.Sprintf("key %q went back from %d to %d", key, last, v))
+			}
+			s.last[key] = v
+		})
+	}
+	for i, s := range writers {
+		i := i
+		var seq int64
+		run(s, func(s *stresser) {
+			// writer i owns the keys whose index is i modulo count of writers.
+			n := (len(keys) - i + len(writers) - 1) / len(writers)
+			if n <= 0 {
+				return
+			}
+			key := keys[i+s.rnd.Intn(n)*len(writers)]
+			seq++
+			ce.Set(key, seq)
+			s.last[key] = seq
+		})
+	}
+	for _, s := range deleters {
+		run(s, func(s *stresser) {
+			ce.Del(keys[s.rnd.Intn(len(keys))])
+		})
+	}
+	start := time.Now()
+	time.Sleep(cfg.Duration)
+	close(stop)
+	wg.Wait()
+	result.Duration = time.Since(start)
+	if err := ce.Sync(); err != nil {
+		result.Inconsistencies = append(result.Inconsistencies, fmt.Sprintf("couldn't sync after the workload: %v", err))
+	}
+
+	var hist histogram
+	for _, s := range readers {
+		result.Reads += s.ops
+		hist.merge(&s.hist)
+		result.Inconsistencies = append(result.Inconsistencies, s.fails...)
+	}
+	result.ReadLatency = hist.latency()
+	hist = histogram{}
+	for _, s := range writers {
+		result.Writes += s.ops
+		hist.merge(&s.hist)
+		for key, last := range s.last {
+			val := ce.Get(key)
+			if val == nil && len(deleters) == 0 {
+				result.Inconsistencies = append(result.Inconsistencies, fmt.Sprintf("key %q is missing, but last write was %d", key, last))
+				continue
+			}
+			if val != nil && val != interface{}(last) {
+				result.Inconsistencies = append(result.Inconsistencies, fmt.Sprintf("key %q has final value %#v, but last write was %d", key, val, last))
+			}
+		}
+	}
+	result.WriteLatency = hist.latency()
+	hist = histogram{}
+	for _, s := range deleters {
+		result.Deletes += s.ops
+		hist.merge(&s.hist)
+	}
+	result.DeleteLatency = hist.latency()
+	return
+}
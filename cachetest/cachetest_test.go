@@ -0,0 +1,64 @@
+package cachetest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	cache "github.com/orkunkaraduman/go-cache"
+)
+
+func TestStressTest(t *testing.T) {
+	ce := cache.NewCache()
+	defer ce.Close()
+	result := StressTest(ce, StressConfig{
+		Readers:  4,
+		Writers:  3,
+		Deleters: 1,
+		Keys:     100,
+		Duration: 300 * time.Millisecond,
+	})
+	if len(result.Inconsistencies) > 0 {
+		t.Fatalf("inconsistencies: %v", result.Inconsistencies)
+	}
+	if result.Reads == 0 || result.Writes == 0 || result.Deletes == 0 {
+		t.Errorf("got %d reads, %d writes, %d deletes", result.Reads, result.Writes, result.Deletes)
+	}
+	if result.Throughput() <= 0 {
+		t.Error("throughput isn't positive")
+	}
+}
+
+func TestStressTestLostWrite(t *testing.T) {
+	ce := cache.NewCache(cache.WithInterceptor(func(op cache.Op, key string, next func() interface{}) interface{} {
+		if op == cache.OpSet {
+			return nil
+		}
+		return next()
+	}))
+	defer ce.Close()
+	result := StressTest(ce, StressConfig{
+		Keys:     1,
+		Duration: 50 * time.Millisecond,
+	})
+	if len(result.Inconsistencies) == 0 || !strings.Contains(result.Inconsistencies[0], "missing") {
+		t.Errorf("lost write isn't reported: %v", result.Inconsistencies)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	var h histogram
+	for i := 1; i <= 100; i++ {
+		h.add(time.Duration(i) * time.Microsecond)
+	}
+	l := h.latency()
+	if l.Max != 100*time.Microsecond {
+		t.Errorf("got max %v", l.Max)
+	}
+	if l.P50 < 50*time.Microsecond || l.P50 > 2*50*time.Microsecond {
+		t.Errorf("got p50 %v", l.P50)
+	}
+	if l.P50 > l.P90 || l.P90 > l.P99 || l.P99 > l.Max {
+		t.Errorf("percentiles aren't ordered: %+v", l)
+	}
+}
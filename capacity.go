@@ -0,0 +1,30 @@
+package cache
+
+// capacityLimit returns the item limit used by the capacity threshold. It returns 0, if there is no limit.
+func (ce *Cache) capacityLimit() int64 {
+	if ce.maxItems > 0 {
+		return int64(ce.maxItems)
+	}
+	if ce.disk != nil {
+		return int64(ce.disk.maxMemItems)
+	}
+	return 0
+}
+
+// checkCapacity calls the capacity threshold callback, if the usage crossed the threshold upward.
+// It must be called by the queue worker while trMu is locked.
+func (ce *Cache) checkCapacity() {
+	if ce.capFn == nil {
+		return
+	}
+	limit := ce.capacityLimit()
+	if limit <= 0 {
+		return
+	}
+	used := int64(ce.tr.Len())
+	above := float64(used) >= ce.capThreshold*float64(limit)
+	if above && !ce.capAbove {
+		go ce.capFn(used, limit)
+	}
+	ce.capAbove = above
+}
@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+)
+
+// ValueCodec encodes and decodes values for binary snapshots. See SaveBinary and LoadBinary.
+type ValueCodec interface {
+	Encode(val interface{}) ([]byte, error)
+	Decode(data []byte) (interface{}, error)
+}
+
+// GobCodec is the default ValueCodec based on encoding/gob.
+// Types of values must be registered by gob.Register, except basic types.
+type GobCodec struct{}
+
+type gobValue struct {
+	Val interface{}
+}
+
+// Encode encodes val by gob.
+func (GobCodec) Encode(val interface{}) (data []byte, err error) {
+	var buf bytes.Buffer
+	if err = gob.NewEncoder(&buf).Encode(&gobValue{Val: val}); err != nil {
+		return
+	}
+	data = buf.Bytes()
+	return
+}
+
+// Decode decodes data by gob.
+func (GobCodec) Decode(data []byte) (val interface{}, err error) {
+	var v gobValue
+	if err = gob.NewDecoder(bytes.NewReader(data)).Decode(&v); err != nil {
+		return
+	}
+	val = v.Val
+	return
+}
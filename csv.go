@@ -0,0 +1,31 @@
+package cache
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/google/btree"
+)
+
+// WriteCSV writes a consistent snapshot of the cache to w as key,value rows in ascending key order.
+// Values are converted to string by valueToString. If valueToString is nil, fmt.Sprint is used.
+func (ce *Cache) WriteCSV(w io.Writer, valueToString func(interface{}) string) (err error) {
+	if valueToString == nil {
+		valueToString = func(val interface{}) string {
+			return fmt.Sprint(val)
+		}
+	}
+	cw := csv.NewWriter(w)
+	ce.snapshot().Ascend(func(i btree.Item) bool {
+		im := i.(item)
+		err = cw.Write([]string{im.Key, valueToString(im.Val)})
+		return err == nil
+	})
+	if err != nil {
+		return
+	}
+	cw.Flush()
+	err = cw.Error()
+	return
+}
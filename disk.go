@@ -0,0 +1,171 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// diskTier holds entries spilled from the tree as files in a directory.
+type diskTier struct {
+	dir         string
+	maxMemItems int
+
+	mu        sync.Mutex
+	keys      map[string]struct{}
+	spilling  map[string]item
+	promoting map[string]struct{}
+	stale     []string
+}
+
+func newDiskTier(dir string, maxMemItems int) *diskTier {
+	return &diskTier{
+		dir:         dir,
+		maxMemItems: maxMemItems,
+		keys:        make(map[string]struct{}),
+		spilling:    make(map[string]item),
+		promoting:   make(map[string]struct{}),
+	}
+}
+
+func (dt *diskTier) path(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return filepath.Join(dt.dir, hex.EncodeToString(h[:]))
+}
+
+// invalidate forgets the spilled entry of given key, because a newer write was committed.
+func (dt *diskTier) invalidate(key string) {
+	dt.mu.Lock()
+	if _, ok := dt.keys[key]; ok {
+		delete(dt.keys, key)
+		dt.stale = append(dt.stale, key)
+	}
+	delete(dt.promoting, key)
+	dt.mu.Unlock()
+}
+
+// clear forgets all spilled entries, and removes their files.
+func (dt *diskTier) clear() {
+	dt.mu.Lock()
+	for key := range dt.keys {
+		dt.stale = append(dt.stale, key)
+	}
+	dt.keys = make(map[string]struct{})
+	dt.promoting = make(map[string]struct{})
+	dt.mu.Unlock()
+	dt.removeStale()
+}
+
+func (dt *diskTier) removeStale() {
+	dt.mu.Lock()
+	stale := dt.stale
+	dt.stale = nil
+	dt.mu.Unlock()
+	for _, key := range stale {
+		os.Remove(dt.path(key))
+	}
+}
+
+// spillOverflow evicts least recently used items to the disk tier while the tree exceeds maxMemItems.
+// It must be called while trMu is locked. The items stay visible to Get until they are written by spill.
+func (ce *Cache) spillOverflow() (items []item) {
+	dt := ce.disk
+	if dt == nil || ce.tr.Len() <= dt.maxMemItems {
+		return
+	}
+	items = ce.evictLocked(ce.tr.Len() - dt.maxMemItems + dt.maxMemItems/64)
+	dt.mu.Lock()
+	for _, im := range items {
+		dt.spilling[im.Key] = im
+	}
+	dt.mu.Unlock()
+	return
+}
+
+// spill writes the items evicted by spillOverflow to files. It must be called without holding any lock.
+// Items couldn't be written are reported to the error handler and to the OnEvict callback.
+func (ce *Cache) spill(items []item) {
+	dt := ce.disk
+	if dt == nil {
+		return
+	}
+	for _, im := range items {
+		data, err := ce.codec.Encode(im.Val)
+		if err == nil {
+			var hdr [8]byte
+			binary.BigEndian.PutUint64(hdr[:], uint64(im.exp))
+			err = ioutil.WriteFile(dt.path(im.Key), append(hdr[:], data...), 0600)
+		}
+		dt.mu.Lock()
+		delete(dt.spilling, im.Key)
+		if err == nil {
+			dt.keys[im.Key] = struct{}{}
+		}
+		dt.mu.Unlock()
+		if err != nil {
+			ce.error(err)
+			if ce.onEvict != nil {
+				ce.onEvict(im.Key, im.Val)
+			}
+		}
+	}
+	dt.removeStale()
+}
+
+// promote reads the entry of given key from the disk tier, and moves it back to memory.
+// It returns nil, if the key wasn't spilled. If the key is rejected by WithMaxNamespaces, the value is returned
+// but the entry is dropped instead of moving back, like an eviction.
+func (ce *Cache) promote(key string) (val interface{}) {
+	dt := ce.disk
+	dt.mu.Lock()
+	if im, ok := dt.spilling[key]; ok {
+		dt.mu.Unlock()
+		if im.visible() {
+			val = im.Val
+		}
+		return
+	}
+	if _, ok := dt.keys[key]; !ok {
+		dt.mu.Unlock()
+		return
+	}
+	delete(dt.keys, key)
+	dt.promoting[key] = struct{}{}
+	dt.mu.Unlock()
+	p := dt.path(key)
+	data, err := ioutil.ReadFile(p)
+	os.Remove(p)
+	var im item
+	if err == nil && len(data) >= 8 {
+		im = ce.newItem(key, nil)
+		im.exp = int64(binary.BigEndian.Uint64(data[:8]))
+		im.Val, err = ce.codec.Decode(data[8:])
+	}
+	if err != nil {
+		ce.error(err)
+	}
+	ce.quMu.Lock()
+	dt.mu.Lock()
+	_, ok := dt.promoting[key]
+	delete(dt.promoting, key)
+	dt.mu.Unlock()
+	if _, queued := ce.queued(key); !ok || queued || im.Val == nil || im.expired() {
+		ce.quMu.Unlock()
+		return
+	}
+	if err = ce.checkNamespace(key); err != nil {
+		ce.quMu.Unlock()
+		ce.error(err)
+		val = im.Val
+		return
+	}
+	ce.enqueue(im)
+	ce.quMu.Unlock()
+	ce.notify()
+	val = im.Val
+	return
+}
@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"github.com/google/btree"
+)
+
+// DrainBatches removes every entry of the cache in ascending key order, passing them to fn in batches up to batchSize.
+// Entries of a batch are deleted only after fn returns nil. If fn returns an error, DrainBatches stops and returns it,
+// and the entries of the failed batch and the rest are retained. So calling DrainBatches again retries the failed batch,
+// fn must tolerate receiving same entries more than once.
+// It drains a snapshot taken at the beginning, writers should be stopped to drain the cache completely.
+// An entry written after the snapshot isn't deleted, so the write isn't lost.
+func (ce *Cache) DrainBatches(batchSize int, fn func(batch []Entry) error) (err error) {
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+	batch := make([]Entry, 0, batchSize)
+	items := make([]item, 0, batchSize)
+	flush := func() {
+		if err = fn(batch); err != nil {
+			return
+		}
+		ce.quMu.Lock()
+		for _, im := range items {
+			if cur, ok := ce.find(im.Key); ok && cur.ws == im.ws {
+				ce.enqueue(ce.newItem(im.Key, nil))
+			}
+		}
+		ce.quMu.Unlock()
+		ce.notify()
+		batch = make([]Entry, 0, batchSize)
+		items = items[:0]
+	}
+	ce.snapshot().Ascend(func(i btree.Item) bool {
+		im := i.(item)
+		batch = append(batch, Entry{Key: im.Key, Val: im.Val})
+		items = append(items, im)
+		if len(batch) >= batchSize {
+			flush()
+		}
+		return err == nil
+	})
+	if err == nil && len(batch) > 0 {
+		flush()
+	}
+	return
+}
@@ -0,0 +1,31 @@
+package cache
+
+import (
+	"testing"
+)
+
+func TestDrainBatchesKeepsLaterWrites(t *testing.T) {
+	ce := NewCache()
+	defer ce.Close()
+	ce.Set("a", 1)
+	ce.Set("b", 2)
+	ce.Sync()
+	var drained int
+	err := ce.DrainBatches(1, func(batch []Entry) error {
+		if batch[0].Key == "a" {
+			ce.Set("a", 10)
+		}
+		drained += len(batch)
+		return nil
+	})
+	if err != nil || drained != 2 {
+		t.Fatalf("got %d drained, err %v", drained, err)
+	}
+	ce.Sync()
+	if val := ce.Get("a"); val != 10 {
+		t.Fatalf("got %v, want the later write 10", val)
+	}
+	if val := ce.Get("b"); val != nil {
+		t.Fatalf("got %v, want b drained", val)
+	}
+}
@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"time"
+)
+
+// Entry is a key-value pair of the cache. Metadata fields are filled only by GetEntry.
+type Entry struct {
+	Key string
+	Val interface{}
+
+	// ExpiresAt is the expiry time of the entry. It is zero, if the entry never expires.
+	ExpiresAt time.Time
+
+	// Version is the version given by Upsert. Writes by other methods reset it to 0.
+	Version uint64
+
+	// WriteTime is the time of the last write of the entry.
+	WriteTime time.Time
+}
+
+// GetEntry returns the entry of given key with its metadata in a single read. It returns false, if the key wasn't exist.
+// Key of the entry is given key, even if it is a lookup key of SetSorted.
+func (ce *Cache) GetEntry(key string) (e Entry, ok bool) {
+	ce.quMu.RLock()
+	im, ok := ce.lookup(key)
+	ce.quMu.RUnlock()
+	if !ok {
+		return
+	}
+	im.hit()
+	e = Entry{Key: key, Val: im.Val, Version: im.ver}
+	if im.exp > 0 {
+		e.ExpiresAt = time.Unix(0, im.exp)
+	}
+	if im.wt > 0 {
+		e.WriteTime = time.Unix(0, im.wt)
+	}
+	return
+}
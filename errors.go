@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNotNumeric is returned when a numeric operation is applied to a non-numeric value.
+	ErrNotNumeric = errors.New("value is not numeric")
+
+	// ErrInvalidSnapshot is returned when a binary snapshot has invalid magic number.
+	ErrInvalidSnapshot = errors.New("invalid snapshot")
+
+	// ErrSnapshotVersion is returned when a binary snapshot has unsupported format version.
+	ErrSnapshotVersion = errors.New("unsupported snapshot version")
+
+	// ErrCorruptSnapshot is returned when a binary snapshot is truncated or its checksum mismatches.
+	ErrCorruptSnapshot = errors.New("corrupt snapshot")
+
+	// ErrTimeout is returned when an operation couldn't acquire the locks in the timeout given by WithOperationTimeout.
+	ErrTimeout = errors.New("operation timed out")
+
+	// ErrNoEvictable is reported to the error handler when eviction is needed but all items are pinned.
+	ErrNoEvictable = errors.New("no evictable item")
+
+	// ErrTooManyNamespaces is returned when a key of a new namespace is rejected by WithMaxNamespaces.
+	ErrTooManyNamespaces = errors.New("too many namespaces")
+)
+
+// KeyCollisionError is reported when a key replaces a different key equal by the comparator. See WithComparatorCollisionCheck.
+type KeyCollisionError struct {
+	Key      string
+	Existing string
+}
+
+func (e *KeyCollisionError) Error() string {
+	return fmt.Sprintf("key %q collides with existing key %q", e.Key, e.Existing)
+}
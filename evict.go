@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"container/heap"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/btree"
+)
+
+// evictHeap is a max-heap of items ordered by last access time.
+type evictHeap []item
+
+func (h evictHeap) Len() int { return len(h) }
+func (h evictHeap) Less(i, j int) bool {
+	return atomic.LoadInt64(&h[i].st.atime) > atomic.LoadInt64(&h[j].st.atime)
+}
+func (h evictHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *evictHeap) Push(x interface{}) { *h = append(*h, x.(item)) }
+func (h *evictHeap) Pop() (x interface{}) {
+	old := *h
+	x = old[len(old)-1]
+	*h = old[:len(old)-1]
+	return
+}
+
+// evictLocked removes n least recently used items from the tree, and returns them. Pinned and reserved items are skipped, and
+// ErrNoEvictable is reported if there is no item to evict.
+// It must be called while trMu is locked. It scans whole tree, so it should be called for batches.
+func (ce *Cache) evictLocked(n int) (evicted []item) {
+	if n <= 0 {
+		return
+	}
+	h := make(evictHeap, 0, n)
+	ce.tr.Ascend(func(i btree.Item) bool {
+		im := i.(item)
+		if im.st == nil || im.pin || isReservation(im.Val) {
+			return true
+		}
+		if h.Len() < n {
+			heap.Push(&h, im)
+		} else if atomic.LoadInt64(&im.st.atime) < atomic.LoadInt64(&h[0].st.atime) {
+			h[0] = im
+			heap.Fix(&h, 0)
+		}
+		return true
+	})
+	if h.Len() == 0 {
+		ce.error(ErrNoEvictable)
+		return
+	}
+	for _, im := range h {
+		ce.tr.Delete(im)
+		ce.removed(im)
+	}
+	evicted = []item(h)
+	return
+}
+
+// evicted calls OnEvict callback for evicted items. It must be called without holding any lock.
+func (ce *Cache) evicted(evicted []item) {
+	if ce.onEvict == nil {
+		return
+	}
+	for _, im := range evicted {
+		ce.onEvict(im.Key, im.Val)
+	}
+}
+
+// evictOverflow evicts items while the tree exceeds the item limit. It must be called while trMu is locked.
+// It evicts about 1/64 of the limit at once to amortize scanning the tree.
+func (ce *Cache) evictOverflow() (evicted []item) {
+	if ce.maxItems <= 0 || ce.tr.Len() <= ce.maxItems {
+		return
+	}
+	return ce.evictLocked(ce.tr.Len() - ce.maxItems + ce.maxItems/64)
+}
+
+// memoryPressureWorker evicts items periodically while the heap in use exceeds the target.
+func (ce *Cache) memoryPressureWorker() {
+	tk := time.NewTicker(ce.memInterval)
+	defer tk.Stop()
+	var ms runtime.MemStats
+	for {
+		select {
+		case <-ce.done:
+			return
+		case <-tk.C:
+		}
+		runtime.ReadMemStats(&ms)
+		if ms.HeapInuse <= ce.memTarget {
+			continue
+		}
+		// the cache is assumed to be the dominant part of the heap; the rest is evicted in next ticks.
+		ce.trMu.Lock()
+		n := int(uint64(ce.tr.Len()) * (ms.HeapInuse - ce.memTarget) / ms.HeapInuse)
+		if n < 1 {
+			n = 1
+		}
+		evicted := ce.evictLocked(n)
+		ce.published(nil, evicted)
+		ce.trMu.Unlock()
+		ce.evicted(evicted)
+	}
+}
+
+// maxAgeWorker flushes the cache every maxAge.
+func (ce *Cache) maxAgeWorker() {
+	tk := time.NewTicker(ce.maxAge)
+	defer tk.Stop()
+	for {
+		select {
+		case <-ce.done:
+			return
+		case <-tk.C:
+		}
+		tr, qu := ce.swap(btree.New(ce.degree))
+		if ce.onEvict == nil {
+			continue
+		}
+		for _, im := range qu {
+			if im.Val != nil {
+				tr.ReplaceOrInsert(im)
+			} else {
+				tr.Delete(im)
+			}
+		}
+		tr.Ascend(func(i btree.Item) bool {
+			if im := i.(item); im.visible() {
+				ce.onEvict(im.Key, im.Val)
+			}
+			return true
+		})
+	}
+}
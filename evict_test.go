@@ -0,0 +1,28 @@
+package cache
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMaxAgeOnEvictSkipsInvisible(t *testing.T) {
+	var mu sync.Mutex
+	evicted := make(map[string]interface{})
+	ce := NewCache(WithMaxAge(100*time.Millisecond), WithOnEvict(func(key string, val interface{}) {
+		mu.Lock()
+		evicted[key] = val
+		mu.Unlock()
+	}))
+	defer ce.Close()
+	ce.Set("a", 1)
+	ce.SetTTL("expired", 2, time.Millisecond)
+	ce.Reserve("reserved")
+	ce.Memoize("nil", func() interface{} { return nil })
+	time.Sleep(150 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(evicted) != 1 || evicted["a"] != 1 {
+		t.Fatalf("got %v, want only a", evicted)
+	}
+}
@@ -0,0 +1,33 @@
+package cache
+
+// flight is an in-flight computation of a key.
+type flight struct {
+	done chan struct{}
+	val  interface{}
+	err  error
+}
+
+// do calls fn for given key, and returns its result. Concurrent calls for the same key wait for the first one,
+// and share its result instead of calling fn.
+func (ce *Cache) do(key string, fn func() (interface{}, error)) (val interface{}, err error) {
+	ce.flMu.Lock()
+	if f, ok := ce.flights[key]; ok {
+		ce.flMu.Unlock()
+		<-f.done
+		return f.val, f.err
+	}
+	f := &flight{done: make(chan struct{})}
+	if ce.flights == nil {
+		ce.flights = make(map[string]*flight)
+	}
+	ce.flights[key] = f
+	ce.flMu.Unlock()
+	defer func() {
+		ce.flMu.Lock()
+		delete(ce.flights, key)
+		ce.flMu.Unlock()
+		close(f.done)
+	}()
+	f.val, f.err = fn()
+	return f.val, f.err
+}
@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"container/heap"
+	"sort"
+	"sync/atomic"
+
+	"github.com/google/btree"
+)
+
+// KeyCount is a key and its read count.
+type KeyCount struct {
+	Key   string
+	Count uint64
+}
+
+type keyCountHeap []KeyCount
+
+func (h keyCountHeap) Len() int            { return len(h) }
+func (h keyCountHeap) Less(i, j int) bool  { return h[i].Count < h[j].Count }
+func (h keyCountHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *keyCountHeap) Push(x interface{}) { *h = append(*h, x.(KeyCount)) }
+func (h *keyCountHeap) Pop() (x interface{}) {
+	old := *h
+	x = old[len(old)-1]
+	*h = old[:len(old)-1]
+	return
+}
+
+// HotKeys returns the top n most read keys in descending order of read count.
+// It requires WithPerKeyStats option, otherwise returns nil. Reads of pending writes are counted after the commit.
+func (ce *Cache) HotKeys(n int) (result []KeyCount) {
+	if !ce.perKeyStats || n <= 0 {
+		return
+	}
+	h := make(keyCountHeap, 0, n)
+	ce.trMu.RLock()
+	ce.tr.Ascend(func(i btree.Item) bool {
+		im := i.(item)
+		if im.st == nil {
+			return true
+		}
+		kc := KeyCount{Key: im.Key, Count: atomic.LoadUint64(&im.st.hits)}
+		if h.Len() < n {
+			heap.Push(&h, kc)
+		} else if kc.Count > h[0].Count {
+			h[0] = kc
+			heap.Fix(&h, 0)
+		}
+		return true
+	})
+	ce.trMu.RUnlock()
+	result = []KeyCount(h)
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Count > result[j].Count
+	})
+	return
+}
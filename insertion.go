@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"sort"
+	"sync/atomic"
+
+	"github.com/google/btree"
+)
+
+// insItem is an item of the insertion order index.
+type insItem struct {
+	seq uint64
+	key string
+}
+
+// Less is implementation of btree.Item.
+func (a insItem) Less(than btree.Item) bool {
+	return a.seq < than.(insItem).seq
+}
+
+// index adds the item to the index. An overwritten entry keeps its sequence, and a new entry keeps the sequence
+// given when it was enqueued. It must be called while trMu is locked.
+func (ce *Cache) index(im *item) {
+	if r := ce.tr.Get(*im); r != nil && r.(item).ins != 0 {
+		im.ins = r.(item).ins
+		return
+	}
+	if im.ins == 0 {
+		im.ins = atomic.AddUint64(&ce.insSeq, 1)
+	}
+	ce.ins.ReplaceOrInsert(insItem{seq: im.ins, key: im.Key})
+}
+
+// unindex removes the item deleted from the tree from the index. It must be called while trMu is locked.
+func (ce *Cache) unindex(im item) {
+	if ce.ins != nil && im.ins != 0 {
+		ce.ins.Delete(insItem{seq: im.ins})
+	}
+}
+
+// reindex rebuilds the index for the replaced tree in key order. It must be called while trMu is locked.
+func (ce *Cache) reindex() {
+	if ce.ins == nil {
+		return
+	}
+	ce.ins = btree.New(DefaultDegree)
+	var items []item
+	ce.tr.Ascend(func(i btree.Item) bool {
+		items = append(items, i.(item))
+		return true
+	})
+	for _, im := range items {
+		im.ins = 0
+		ce.index(&im)
+		ce.tr.ReplaceOrInsert(im)
+	}
+}
+
+// RangeByInsertion calls fn for every entry in the order they were first inserted until fn returns false.
+// Overwriting an entry keeps its position, and deleting and setting it again moves it to the end, unless the deletion
+// is coalesced with the write in the queue. Entries loaded by Load or LoadBinary are ordered by key, and entries
+// promoted from the disk tier are ordered as new entries.
+// It requires WithInsertionOrder, otherwise it does nothing. It sorts all entries, so it is O(n log n).
+// The walk is done on a consistent snapshot, so fn can safely use the cache.
+func (ce *Cache) RangeByInsertion(fn func(key string, val interface{}) bool) {
+	ce.quMu.RLock()
+	ce.trMu.Lock()
+	if ce.ins == nil {
+		ce.trMu.Unlock()
+		ce.quMu.RUnlock()
+		return
+	}
+	tr, ins := ce.tr.Clone(), ce.ins.Clone()
+	ce.trMu.Unlock()
+	pending := make(map[string]item, len(ce.cm)+len(ce.qu))
+	for _, qu := range []map[string]item{ce.cm, ce.qu} {
+		for key, im := range qu {
+			pending[key] = im
+		}
+	}
+	ce.quMu.RUnlock()
+	items := make([]item, 0, ins.Len()+len(pending))
+	ins.Ascend(func(i btree.Item) bool {
+		ii := i.(insItem)
+		im, found := pending[ii.key]
+		if found {
+			delete(pending, ii.key)
+			im.ins = ii.seq
+		} else if r := tr.Get(ce.probe(ii.key)); r != nil {
+			im = r.(item)
+		}
+		if im.Val != nil && im.visible() {
+			items = append(items, im)
+		}
+		return true
+	})
+	for _, im := range pending {
+		if im.Val != nil && im.visible() {
+			items = append(items, im)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ins < items[j].ins })
+	for _, im := range items {
+		if !fn(im.Key, im.Val) {
+			return
+		}
+	}
+}
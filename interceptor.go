@@ -0,0 +1,32 @@
+package cache
+
+// Op is an operation of the cache passed to Interceptor.
+type Op int
+
+const (
+	// OpGet is Get operation.
+	OpGet Op = iota
+
+	// OpSet is Set operation.
+	OpSet
+
+	// OpDel is Del operation.
+	OpDel
+)
+
+func (op Op) String() string {
+	switch op {
+	case OpGet:
+		return "get"
+	case OpSet:
+		return "set"
+	case OpDel:
+		return "del"
+	}
+	return "unknown"
+}
+
+// Interceptor is called around an operation on given key. next does the operation, and returns the value for OpGet
+// or nil for other operations. The interceptor may skip next to short-circuit; its result is returned by Get.
+// It runs on the calling goroutine, not the queue worker, so a Set is intercepted when it is enqueued, not committed.
+type Interceptor func(op Op, key string, next func() interface{}) interface{}
@@ -2,6 +2,8 @@ package cache
 
 import (
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/google/btree"
 )
@@ -9,12 +11,64 @@ import (
 type item struct {
 	Key string
 	Val interface{}
+	st  *itemStat
+	exp int64
+	ver uint64
+	wt  int64
+	ws  uint64
+	pin bool
+	ins uint64
+	cmp func(a, b string) int
+}
+
+// itemStat holds access statistics of an item. It is shared by copies of the item.
+type itemStat struct {
+	hits  uint64
+	atime int64
 }
 
 func (a item) Less(b btree.Item) bool {
 	if c, ok := b.(item); ok {
+		if a.cmp != nil {
+			return a.cmp(a.Key, c.Key) < 0
+		}
 		result := strings.Compare(a.Key, c.Key) < 0
 		return result
 	}
 	return false
 }
+
+func (a item) hit() {
+	if a.st != nil {
+		atomic.AddUint64(&a.st.hits, 1)
+		atomic.StoreInt64(&a.st.atime, time.Now().UnixNano())
+	}
+}
+
+// expired reports whether the item has an expiry time and it has passed. Pinned items never expire.
+func (a item) expired() bool {
+	return a.exp > 0 && !a.pin && a.exp <= time.Now().UnixNano()
+}
+
+// event returns the event of the committed item. An item isn't visible to reads is published as a delete,
+// so placeholders like reservations and memoized nils never reach subscribers.
+func (a item) event() Event {
+	if !a.visible() {
+		return Event{Key: a.Key}
+	}
+	return Event{Key: a.Key, Val: a.Val}
+}
+
+// placeholder reports whether the value is an internal placeholder, a reservation or a memoized nil.
+func (a item) placeholder() bool {
+	switch a.Val.(type) {
+	case reservation, memoNil:
+		return true
+	}
+	return false
+}
+
+// visible reports whether the item is visible to reads, it isn't expired or a placeholder.
+func (a item) visible() bool {
+	return !a.placeholder() && !a.expired()
+}
@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"strings"
+)
+
+// CompositeKey encodes parts into a key. Every part is terminated by "\x00\x01", and "\x00" in parts is escaped as "\x00\xff".
+// The encoding preserves order of parts, so keys are sorted by the first part, then by the second part and so on.
+// A composite key of some leading parts is a prefix of exactly the keys start with these parts, so it can be used
+// by WatchPrefix and range scans. See SplitKey.
+func CompositeKey(parts ...string) string {
+	var sb strings.Builder
+	for _, p := range parts {
+		sb.WriteString(strings.ReplaceAll(p, "\x00", "\x00\xff"))
+		sb.WriteString("\x00\x01")
+	}
+	return sb.String()
+}
+
+// SplitKey decodes a key encoded by CompositeKey into its parts. A trailing part without terminator is returned as is,
+// and a "\x00" not followed by "\xff" or "\x01" is kept as is.
+func SplitKey(key string) (parts []string) {
+	var sb strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c != 0 {
+			sb.WriteByte(c)
+			continue
+		}
+		if i+1 < len(key) && key[i+1] == 0x01 {
+			parts = append(parts, sb.String())
+			sb.Reset()
+			i++
+			continue
+		}
+		sb.WriteByte(0)
+		if i+1 < len(key) && key[i+1] == 0xff {
+			i++
+		}
+	}
+	if sb.Len() > 0 {
+		parts = append(parts, sb.String())
+	}
+	return
+}
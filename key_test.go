@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestCompositeKeyRoundTrip(t *testing.T) {
+	for _, parts := range [][]string{
+		{"a"},
+		{"a", "b"},
+		{"a", "\xff"},
+		{"a\x00"},
+		{"a\x00", "x"},
+		{"\x00\x01", "\x00\xff", ""},
+	} {
+		if got := SplitKey(CompositeKey(parts...)); !reflect.DeepEqual(got, parts) {
+			t.Errorf("SplitKey(CompositeKey(%q)) = %q", parts, got)
+		}
+	}
+}
+
+func TestCompositeKeyUnambiguous(t *testing.T) {
+	if CompositeKey("a", "\xff") == CompositeKey("a\x00") {
+		t.Error("different parts give the same key")
+	}
+	if strings.HasPrefix(CompositeKey("a\x00", "x"), CompositeKey("a")) {
+		t.Error("false prefix match")
+	}
+	if !strings.HasPrefix(CompositeKey("a", "x"), CompositeKey("a")) {
+		t.Error("missing prefix match")
+	}
+}
+
+func TestCompositeKeyOrder(t *testing.T) {
+	tuples := [][]string{
+		{"a", "z"},
+		{"a\x00", "a"},
+		{"ab", "a"},
+		{"", "b"},
+		{"a", ""},
+		{"a\xff", "a"},
+	}
+	keys := make([]string, len(tuples))
+	for i, parts := range tuples {
+		keys[i] = CompositeKey(parts...)
+	}
+	sort.Strings(keys)
+	sort.Slice(tuples, func(i, j int) bool {
+		a, b := tuples[i], tuples[j]
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		return a[1] < b[1]
+	})
+	for i, parts := range tuples {
+		if got := SplitKey(keys[i]); !reflect.DeepEqual(got, parts) {
+			t.Errorf("position %d: got %q, want %q", i, got, parts)
+		}
+	}
+}
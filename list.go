@@ -0,0 +1,88 @@
+package cache
+
+// PushFront inserts val at the front of the []interface{} value of given key atomically, and returns the new length.
+// It creates the slice if the key wasn't exist. If WithMaxListLen option is given, elements over the limit are dropped
+// from the back. The stored slice is copied instead of modified, so slices returned by Get are never changed.
+// If the value isn't []interface{}, it is left untouched and PushFront returns 0.
+func (ce *Cache) PushFront(key string, val interface{}) (length int) {
+	return ce.push(key, val, true)
+}
+
+// PushBack is like PushFront, but it appends val at the back, and drops elements over the limit from the front.
+func (ce *Cache) PushBack(key string, val interface{}) (length int) {
+	return ce.push(key, val, false)
+}
+
+// PopFront removes the first element of the []interface{} value of given key atomically, and returns it.
+// The key is deleted when the last element is popped. It returns false, if the key wasn't exist,
+// the slice was empty or the value isn't []interface{}.
+func (ce *Cache) PopFront(key string) (val interface{}, ok bool) {
+	return ce.pop(key, true)
+}
+
+// PopBack is like PopFront, but it removes the last element.
+func (ce *Cache) PopBack(key string) (val interface{}, ok bool) {
+	return ce.pop(key, false)
+}
+
+func (ce *Cache) push(key string, val interface{}, front bool) (length int) {
+	ce.quMu.Lock()
+	im, ok := ce.present(key)
+	var old []interface{}
+	if ok {
+		var isList bool
+		if old, isList = im.Val.([]interface{}); !isList {
+			ce.quMu.Unlock()
+			return
+		}
+	} else if err := ce.checkNamespace(key); err != nil {
+		ce.quMu.Unlock()
+		ce.error(err)
+		return
+	}
+	l := make([]interface{}, 0, len(old)+1)
+	if front {
+		l = append(append(l, val), old...)
+	} else {
+		l = append(append(l, old...), val)
+	}
+	if ce.maxListLen > 0 && len(l) > ce.maxListLen {
+		if front {
+			l = l[:ce.maxListLen]
+		} else {
+			l = l[len(l)-ce.maxListLen:]
+		}
+	}
+	ce.enqueue(ce.updated(key, im, l))
+	ce.quMu.Unlock()
+	ce.notify()
+	length = len(l)
+	return
+}
+
+func (ce *Cache) pop(key string, front bool) (val interface{}, ok bool) {
+	ce.quMu.Lock()
+	im, ok := ce.lookup(key)
+	old, isList := im.Val.([]interface{})
+	if !ok || !isList || len(old) == 0 {
+		ce.quMu.Unlock()
+		ok = false
+		return
+	}
+	var l []interface{}
+	if front {
+		val = old[0]
+		l = append(l, old[1:]...)
+	} else {
+		val = old[len(old)-1]
+		l = append(l, old[:len(old)-1]...)
+	}
+	if len(l) == 0 {
+		ce.enqueue(ce.newItem(key, nil))
+	} else {
+		ce.enqueue(ce.updated(key, im, l))
+	}
+	ce.quMu.Unlock()
+	ce.notify()
+	return
+}
@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/google/btree"
+)
+
+// maintenanceChunkSize is count of entries Maintenance reads and writes by one lock of the queue.
+const maintenanceChunkSize = 256
+
+// Maintenance applies fn to every entry of the cache in ascending key order, and writes newVal or deletes the entry.
+// Entries are processed in chunks: a chunk is read while the queue is read-locked, fn is called without holding
+// any lock, and the results are written while the queue is locked. So fn never blocks readers or writers.
+// A result is written only if the entry hasn't been written since the read, like Transaction; otherwise fn is called
+// again for the current value while the queue is locked. So every entry is transformed atomically against its current
+// value, fn may be called twice for an entry and it must be pure. fn must not use the cache.
+// progress is called after every chunk with count of processed and total entries, if it isn't nil.
+// Entries added after Maintenance started aren't visited. It stops and returns ctx.Err() if ctx is done.
+func (ce *Cache) Maintenance(ctx context.Context, fn func(key string, val interface{}) (newVal interface{}, delete bool), progress func(done, total int)) (err error) {
+	tr := ce.snapshot()
+	keys := make([]string, 0, tr.Len())
+	tr.Ascend(func(i btree.Item) bool {
+		keys = append(keys, i.(item).Key)
+		return true
+	})
+	type result struct {
+		im     item
+		newVal interface{}
+	}
+	transform := func(im item) interface{} {
+		newVal, del := fn(im.Key, im.Val)
+		if del {
+			newVal = nil
+		}
+		return newVal
+	}
+	results := make([]result, 0, maintenanceChunkSize)
+	for done := 0; done < len(keys); {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+		end := done + maintenanceChunkSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		results = results[:0]
+		ce.quMu.RLock()
+		for _, key := range keys[done:end] {
+			if im, ok := ce.lookup(key); ok {
+				results = append(results, result{im: im})
+			}
+		}
+		ce.quMu.RUnlock()
+		for i := range results {
+			results[i].newVal = transform(results[i].im)
+		}
+		ce.quMu.Lock()
+		for _, r := range results {
+			cur, ok := ce.lookup(r.im.Key)
+			if !ok {
+				continue
+			}
+			if cur.ws != r.im.ws {
+				r.newVal = transform(cur)
+			}
+			im2 := ce.newTreeItem(cur.Key, r.newVal)
+			im2.exp, im2.ver = cur.exp, cur.ver
+			ce.enqueue(im2)
+		}
+		ce.quMu.Unlock()
+		if len(results) > 0 {
+			ce.notify()
+		}
+		done = end
+		if progress != nil {
+			progress(done, len(keys))
+		}
+	}
+	return
+}
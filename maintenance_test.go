@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMaintenanceDoesntBlock(t *testing.T) {
+	ce := NewCache()
+	defer ce.Close()
+	ce.Set("a", 1)
+	ce.Set("b", 2)
+	ce.Sync()
+	started := make(chan struct{})
+	var once sync.Once
+	done := make(chan error)
+	go func() {
+		done <- ce.Maintenance(context.Background(), func(key string, val interface{}) (interface{}, bool) {
+			once.Do(func() {
+				close(started)
+				time.Sleep(100 * time.Millisecond)
+			})
+			return val.(int) * 2, false
+		}, nil)
+	}()
+	<-started
+	start := time.Now()
+	if val := ce.Get("a"); val != 1 {
+		t.Errorf("got %v, want 1", val)
+	}
+	ce.Set("b", 10)
+	if d := time.Since(start); d > 50*time.Millisecond {
+		t.Errorf("Get and Set were blocked for %v", d)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if val := ce.Get("a"); val != 2 {
+		t.Errorf("got %v, want 2", val)
+	}
+	if val := ce.Get("b"); val != 20 {
+		t.Errorf("got %v, want the write during fn transformed to 20", val)
+	}
+}
@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// memoNil is the stored value of a memoized nil result.
+type memoNil struct{}
+
+// memoized returns the memoized value of given key. ok is false, if the key wasn't memoized.
+func (ce *Cache) memoized(key string) (val interface{}, ok bool) {
+	ce.quMu.RLock()
+	im, found := ce.find(key)
+	ce.quMu.RUnlock()
+	if !found {
+		return
+	}
+	if _, ok = im.Val.(memoNil); ok {
+		return
+	}
+	if ok = im.visible(); ok {
+		val = im.Val
+	}
+	return
+}
+
+// Memoize returns the value of given key, or calls compute once and caches its result for the key.
+// Concurrent calls for the same key wait for a single compute call. The result is cached forever, or with the TTL
+// given by WithMemoizeTTL. Nil results are cached too, so compute is never called again for a key has a value.
+// If the key is reserved, it waits for the reservation like Await, and calls compute only if the reservation is cancelled.
+// If the key is rejected by WithMaxNamespaces, the result is returned without caching and the error is reported to the error handler.
+// It is the go-to method for expensive pure computations.
+func (ce *Cache) Memoize(key string, compute func() interface{}) interface{} {
+	if val, ok := ce.memoized(key); ok {
+		return val
+	}
+	val, _ := ce.do(key, func() (interface{}, error) {
+		if val, ok := ce.memoized(key); ok {
+			return val, nil
+		}
+		if val, ok := ce.Await(context.Background(), key); ok {
+			return val, nil
+		}
+		val := compute()
+		stored := val
+		if stored == nil {
+			stored = memoNil{}
+		}
+		im := ce.newItem(key, stored)
+		im.exp = expiry(ce.memoizeTTL)
+		if im.exp > 0 || val == nil {
+			atomic.StoreInt32(&ce.hidden, 1)
+		}
+		ce.quMu.Lock()
+		if cur, ok := ce.find(key); ok && isReservation(cur.Val) {
+			ce.quMu.Unlock()
+			return val, nil
+		}
+		if err := ce.checkNamespace(key); err != nil {
+			ce.quMu.Unlock()
+			ce.error(err)
+			return val, nil
+		}
+		ce.enqueue(im)
+		ce.quMu.Unlock()
+		ce.notify()
+		return val, nil
+	})
+	return val
+}
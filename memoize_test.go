@@ -0,0 +1,23 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoizeNilEvent(t *testing.T) {
+	ce := NewCache()
+	defer ce.Close()
+	ch, stop := ce.Watch()
+	defer stop()
+	ce.Memoize("k", func() interface{} { return nil })
+	ce.Sync()
+	select {
+	case e := <-ch:
+		if e.Key != "k" || e.Val != nil {
+			t.Errorf("got %+v, want a delete", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event")
+	}
+}
@@ -0,0 +1,54 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// moveMu serializes Move calls, so queue locks of two caches are never acquired in opposite orders.
+var moveMu sync.Mutex
+
+// Move moves the entry of given key from a cache to another cache, and returns false if the key wasn't exist in from.
+// The entry is written to to and deleted from from while queues of both caches are locked, so the value can't be lost
+// and the expiry is preserved. Because the caches are separate, a concurrent reader of both caches may see the entry
+// in both or neither of them depending on the order of its reads. If the key is rejected by WithMaxNamespaces of to,
+// the entry stays in from, Move returns false and the error is reported to the error handler of to.
+func Move(from, to *Cache, key string) (ok bool) {
+	if from == to {
+		from.quMu.Lock()
+		_, ok = from.lookup(key)
+		from.quMu.Unlock()
+		return
+	}
+	moveMu.Lock()
+	from.quMu.Lock()
+	to.quMu.Lock()
+	moveMu.Unlock()
+	im, ok := from.lookup(key)
+	if !ok {
+		to.quMu.Unlock()
+		from.quMu.Unlock()
+		return
+	}
+	if _, exists := to.present(key); !exists {
+		if err := to.checkNamespace(key); err != nil {
+			to.quMu.Unlock()
+			from.quMu.Unlock()
+			to.error(err)
+			ok = false
+			return
+		}
+	}
+	im2 := to.newItem(key, im.Val)
+	im2.exp, im2.wt = im.exp, im.wt
+	to.enqueue(im2)
+	from.enqueue(from.newItem(key, nil))
+	to.quMu.Unlock()
+	from.quMu.Unlock()
+	if im2.exp > 0 {
+		atomic.StoreInt32(&to.hidden, 1)
+	}
+	to.notify()
+	from.notify()
+	return
+}
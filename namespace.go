@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"strings"
+
+	"github.com/google/btree"
+)
+
+// namespace returns the namespace of given key by the delimiter of WithMaxNamespaces.
+func (ce *Cache) namespace(key string) string {
+	if i := strings.Index(key, ce.nsDelimiter); i >= 0 && ce.nsDelimiter != "" {
+		return key[:i]
+	}
+	return key
+}
+
+// countNamespace adds d to count of entries of the key's namespace. It must be called while trMu is locked.
+func (ce *Cache) countNamespace(key string, d int) {
+	if ce.maxNamespaces <= 0 {
+		return
+	}
+	ns := ce.namespace(key)
+	if ce.namespaces[ns]+d <= 0 {
+		delete(ce.namespaces, ns)
+		return
+	}
+	ce.namespaces[ns] += d
+}
+
+// recountNamespaces counts entries of namespaces in the replaced tree. It must be called while trMu is locked.
+func (ce *Cache) recountNamespaces() {
+	if ce.maxNamespaces <= 0 {
+		return
+	}
+	ce.namespaces = make(map[string]int)
+	ce.tr.Ascend(func(i btree.Item) bool {
+		ce.countNamespace(i.(item).Key, 1)
+		return true
+	})
+}
+
+// queueNamespace adds d to count of pending writes of the key's namespace. It must be called while quMu is locked.
+func (ce *Cache) queueNamespace(key string, d int) {
+	if ce.maxNamespaces <= 0 {
+		return
+	}
+	ns := ce.namespace(key)
+	if ce.nsQueued[ns]+d <= 0 {
+		delete(ce.nsQueued, ns)
+		return
+	}
+	ce.nsQueued[ns] += d
+}
+
+// checkNamespace returns ErrTooManyNamespaces, if the key's namespace is new and the limit is reached.
+// Namespaces of committed entries and pending writes are counted together. It must be called while quMu is locked.
+func (ce *Cache) checkNamespace(key string) (err error) {
+	if ce.maxNamespaces <= 0 {
+		return
+	}
+	ns := ce.namespace(key)
+	if _, ok := ce.nsQueued[ns]; ok {
+		return
+	}
+	ce.trMu.RLock()
+	defer ce.trMu.RUnlock()
+	if _, ok := ce.namespaces[ns]; ok {
+		return
+	}
+	n := len(ce.namespaces)
+	for qns := range ce.nsQueued {
+		if _, ok := ce.namespaces[qns]; !ok {
+			n++
+		}
+	}
+	if n >= ce.maxNamespaces {
+		err = ErrTooManyNamespaces
+	}
+	return
+}
+
+// DelNamespace deletes all keys have given prefix in one atomic step, calls the OnEvict callback for every deleted entry,
+// and returns count of deleted entries and sum of their sizes estimated by the size func given by WithSizeFunc.
+// freedBytes is 0 without a size func. The callback is called after the deletion without holding any lock.
+func (ce *Cache) DelNamespace(prefix string) (count int, freedBytes int64) {
+	ce.quMu.Lock()
+	var victims []item
+	ce.ascendRange(ce.snapshotLocked(), prefix, "", func(im item) bool {
+		if !strings.HasPrefix(im.Key, prefix) {
+			return false
+		}
+		if im.visible() {
+			victims = append(victims, im)
+		}
+		return true
+	})
+	for _, im := range victims {
+		ce.enqueue(ce.newItem(im.Key, nil))
+	}
+	ce.quMu.Unlock()
+	if len(victims) == 0 {
+		return
+	}
+	ce.notify()
+	for _, im := range victims {
+		if ce.sizeFn != nil {
+			freedBytes += ce.sizeFn(im.Val)
+		}
+		if ce.onEvict != nil {
+			ce.onEvict(im.Key, im.Val)
+		}
+	}
+	count = len(victims)
+	return
+}
@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"bytes"
+	"fmt"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMaxNamespacesCountsPending(t *testing.T) {
+	ce := NewCache(WithMaxNamespaces(2, ":"))
+	defer ce.Close()
+	rejected := 0
+	for i := 0; i < 10; i++ {
+		if err := ce.SetErr(fmt.Sprintf("ns%d:k", i), i); err == ErrTooManyNamespaces {
+			rejected++
+		}
+	}
+	if rejected != 8 {
+		t.Errorf("rejected %d, want 8", rejected)
+	}
+	if err := ce.SetErr("ns0:other", 1); err != nil {
+		t.Errorf("existing namespace rejected: %v", err)
+	}
+	if err := ce.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(ce.Keys()); n != 3 {
+		t.Errorf("got %d entries, want 3", n)
+	}
+}
+
+func TestMaxNamespacesReleased(t *testing.T) {
+	ce := NewCache(WithMaxNamespaces(1, ":"))
+	defer ce.Close()
+	ce.Set("a:1", 1)
+	if ce.CancelPending("a:1") {
+		if err := ce.SetErr("b:1", 1); err != nil {
+			t.Errorf("cancelled namespace is counted: %v", err)
+		}
+		ce.Del("b:1")
+	} else {
+		ce.Del("a:1")
+	}
+	if err := ce.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ce.SetErr("c:1", 1); err != nil {
+		t.Errorf("deleted namespace is counted: %v", err)
+	}
+}
+
+func TestMaxNamespacesInsertPaths(t *testing.T) {
+	var reported int32
+	ce := NewCache(WithMaxNamespaces(1, ":"), WithErrorHandler(func(err error) {
+		if err == ErrTooManyNamespaces {
+			atomic.AddInt32(&reported, 1)
+		}
+	}))
+	defer ce.Close()
+	ce.Set("a:1", 1)
+	want := int32(0)
+	check := func(name string) {
+		want++
+		if n := atomic.LoadInt32(&reported); n != want {
+			t.Errorf("%s: reported %d, want %d", name, n, want)
+			want = n
+		}
+	}
+	if val, found := ce.GetOrSet("b:1", 1); val != nil || found {
+		t.Errorf("GetOrSet: got %v, %v", val, found)
+	}
+	check("GetOrSet")
+	if _, _, err := ce.GetOrSetErr("b:1", 1); err != ErrTooManyNamespaces {
+		t.Errorf("GetOrSetErr: got %v", err)
+	}
+	ce.SetTTL("b:1", 1, 0)
+	check("SetTTL")
+	if ce.Upsert("b:1", 1, 1) {
+		t.Error("Upsert wrote a new namespace")
+	}
+	check("Upsert")
+	if vals := ce.IncMulti(map[string]int64{"a:1": 1, "b:1": 1}); len(vals) != 1 {
+		t.Errorf("IncMulti: got %v", vals)
+	}
+	check("IncMulti")
+	ce.MergeMap("b:1", map[string]int{"x": 1})
+	check("MergeMap")
+	if val, created := ce.IncDetailed("b:1", 1); val != 0 || created {
+		t.Errorf("IncDetailed: got %v, %v", val, created)
+	}
+	check("IncDetailed")
+	if n := ce.PushBack("b:1", 1); n != 0 {
+		t.Errorf("PushBack: got %v", n)
+	}
+	check("PushBack")
+	if ce.SetIf("b:1", 1, func(interface{}, bool) bool { return true }) {
+		t.Error("SetIf wrote a new namespace")
+	}
+	check("SetIf")
+	if ce.Transaction("b:1", func(interface{}, bool) (interface{}, bool) { return 1, true }) {
+		t.Error("Transaction wrote a new namespace")
+	}
+	check("Transaction")
+	if _, ok := ce.Reserve("b:1"); ok {
+		t.Error("Reserve wrote a new namespace")
+	}
+	check("Reserve")
+	if val := ce.Memoize("b:1", func() interface{} { return 1 }); val != 1 {
+		t.Errorf("Memoize: got %v", val)
+	}
+	check("Memoize")
+	if _, err := ce.Once("b:1", func() (interface{}, error) { return 1, nil }); err != ErrTooManyNamespaces {
+		t.Errorf("Once: got %v", err)
+	}
+	ce.Update(func(tx *Tx) {
+		if err := tx.Set("a:2", 2); err != nil {
+			t.Errorf("Tx.Set: %v", err)
+		}
+		if err := tx.Set("b:1", 1); err != ErrTooManyNamespaces {
+			t.Errorf("Tx.Set: got %v", err)
+		}
+	})
+
+	var buf bytes.Buffer
+	src := NewCache()
+	defer src.Close()
+	src.Set("a:3", 3)
+	src.Set("b:1", 1)
+	src.Sync()
+	if err := src.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := ce.LoadMerge(&buf, nil); err != ErrTooManyNamespaces {
+		t.Errorf("LoadMerge: got %v", err)
+	}
+	if Move(src, ce, "b:1") {
+		t.Error("Move wrote a new namespace")
+	}
+	check("Move")
+	if val := src.Get("b:1"); val != 1 {
+		t.Errorf("Move lost the entry: got %v", val)
+	}
+
+	if err := ce.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if val := ce.Get("b:1"); val != nil {
+		t.Errorf("b:1 was written: %v", val)
+	}
+	if val := ce.Get("a:3"); val != 3 {
+		t.Errorf("LoadMerge skipped a:3: got %v", val)
+	}
+	if val := ce.Get("a:2"); val != 2 {
+		t.Errorf("Tx.Set skipped a:2: got %v", val)
+	}
+}
@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"testing"
+)
+
+func TestSkipNoOpWrites(t *testing.T) {
+	for _, skip := range []bool{true, false} {
+		opts := []Option{WithStats()}
+		if skip {
+			opts = append(opts, WithSkipNoOpWrites())
+		}
+		ce := NewCache(opts...)
+		ce.Set("k", 5)
+		before := ce.Stats().Writes
+		ce.GetAndSet("k", func(val interface{}) interface{} { return val })
+		ce.GetAndMaybeSet("k", func(val interface{}) (interface{}, bool) { return val, true })
+		ce.Inc("k", 0)
+		writes := ce.Stats().Writes - before
+		if skip && writes != 0 {
+			t.Errorf("enqueued %d no-op writes with WithSkipNoOpWrites", writes)
+		}
+		if !skip && writes != 3 {
+			t.Errorf("enqueued %d writes without WithSkipNoOpWrites, want 3", writes)
+		}
+		if val := ce.Get("k"); val != 5 {
+			t.Errorf("got %v, want 5", val)
+		}
+		ce.Close()
+	}
+}
+
+func TestSkipNoOpWritesKeepsChanges(t *testing.T) {
+	ce := NewCache(WithStats(), WithSkipNoOpWrites())
+	defer ce.Close()
+	ce.Set("k", 5)
+	before := ce.Stats().Writes
+	ce.Inc("k", 1)
+	ce.GetAndSet("k", func(val interface{}) interface{} { return val.(int) * 2 })
+	if writes := ce.Stats().Writes - before; writes != 2 {
+		t.Errorf("enqueued %d writes, want 2", writes)
+	}
+	if val := ce.Get("k"); val != 12 {
+		t.Errorf("got %v, want 12", val)
+	}
+}
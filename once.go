@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"context"
+)
+
+// Once returns the value of given key, or calls init and caches its result for the key, if the key wasn't exist.
+// Concurrent calls for the same key wait for a single init call and share its result, so init runs at most once
+// while the entry lives. Unlike Memoize, errors aren't cached: if init returns an error, it is returned to the waiting
+// callers, and the next call retries init. A nil result isn't cached either.
+// If the key is reserved, it waits for the reservation like Await, and calls init only if the reservation is cancelled.
+// If the key is rejected by WithMaxNamespaces, the result isn't cached and it is returned with ErrTooManyNamespaces.
+func (ce *Cache) Once(key string, init func() (interface{}, error)) (val interface{}, err error) {
+	var ok bool
+	if val, ok = ce.Await(context.Background(), key); ok {
+		return
+	}
+	return ce.do(key, func() (val interface{}, err error) {
+		var ok bool
+		if val, ok = ce.Await(context.Background(), key); ok {
+			return
+		}
+		if val, err = init(); err != nil || val == nil {
+			return
+		}
+		ce.quMu.Lock()
+		if im, ok := ce.present(key); ok {
+			if im.visible() {
+				val = im.Val
+			}
+			ce.quMu.Unlock()
+			return
+		}
+		if err = ce.checkNamespace(key); err != nil {
+			ce.quMu.Unlock()
+			return
+		}
+		ce.enqueue(ce.newItem(key, val))
+		ce.quMu.Unlock()
+		ce.notify()
+		return
+	})
+}
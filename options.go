@@ -0,0 +1,243 @@
+package cache
+
+import (
+	"time"
+
+	"github.com/google/btree"
+)
+
+// Option configures a Cache. Options are given to NewCache or NewCacheDegree.
+type Option func(ce *Cache)
+
+// WithPerKeyStats enables counting reads of every key by Get. See HotKeys.
+func WithPerKeyStats() Option {
+	return func(ce *Cache) {
+		ce.perKeyStats = true
+		ce.statItems = true
+	}
+}
+
+// WithStrictNumeric makes Inc, Dec and IncFloat panic with ErrNotNumeric when the existing value isn't numeric.
+// By default they silently return the old value.
+func WithStrictNumeric() Option {
+	return func(ce *Cache) {
+		ce.strictNumeric = true
+	}
+}
+
+// WithValueCodec sets the ValueCodec used by binary snapshots. GobCodec is used by default.
+func WithValueCodec(codec ValueCodec) Option {
+	return func(ce *Cache) {
+		ce.codec = codec
+	}
+}
+
+// WithOnEvict sets a callback called for every item evicted by capacity or memory limits.
+// It is called by the goroutine does eviction, so it shouldn't block.
+func WithOnEvict(fn func(key string, val interface{})) Option {
+	return func(ce *Cache) {
+		ce.onEvict = fn
+	}
+}
+
+// WithMaxItems limits count of committed items. Least recently used items are evicted by the queue worker
+// when the limit is exceeded. See WithOnEvict.
+func WithMaxItems(n int) Option {
+	return func(ce *Cache) {
+		ce.maxItems = n
+		ce.statItems = true
+	}
+}
+
+// WithMemoryPressureEviction evicts least recently used items while the heap in use of the process exceeds targetHeapBytes.
+// The heap is checked by runtime.ReadMemStats every interval. ReadMemStats is relatively expensive,
+// so the interval shouldn't be tiny. It uses the same eviction as WithMaxItems. See WithOnEvict.
+func WithMemoryPressureEviction(targetHeapBytes uint64, interval time.Duration) Option {
+	return func(ce *Cache) {
+		ce.memTarget = targetHeapBytes
+		ce.memInterval = interval
+		ce.statItems = true
+	}
+}
+
+// WithFrequencySketch enables counting every Get call in a count-min sketch. See EstimateFrequency.
+// The sketch uses fixed 2 MiB memory regardless of count of keys.
+func WithFrequencySketch() Option {
+	return func(ce *Cache) {
+		ce.sketch = new(countMinSketch)
+	}
+}
+
+// WithTryTimeout sets the timeout of Try methods to acquire locks. DefaultTryTimeout is used by default.
+func WithTryTimeout(d time.Duration) Option {
+	return func(ce *Cache) {
+		ce.tryTimeout = d
+	}
+}
+
+// WithSkipNoOpWrites makes GetAndSet, GetAndMaybeSet and the methods based on them like Inc and Dec skip enqueuing a write,
+// if the new value is comparable and equal to the old value. It avoids worker wakeups and change events for no-op transforms.
+func WithSkipNoOpWrites() Option {
+	return func(ce *Cache) {
+		ce.skipNoOpWrites = true
+	}
+}
+
+// WithDefaultDescending makes Range, Keys and All iterate in descending key order.
+// It affects only the iteration order, not storage or lookup.
+func WithDefaultDescending() Option {
+	return func(ce *Cache) {
+		ce.descending = true
+	}
+}
+
+// WithMaxAge flushes the whole cache every d, and calls the OnEvict callback for the cleared visible entries if it is given.
+// The cache is empty just after every flush, until it is filled again.
+func WithMaxAge(d time.Duration) Option {
+	return func(ce *Cache) {
+		ce.maxAge = d
+	}
+}
+
+// WithCommitBatchSize limits count of items the queue worker commits while holding the tree lock.
+// The worker releases the lock after every n items to let readers through. n <= 0 means no limit.
+// DefaultCommitBatchSize is used by default.
+func WithCommitBatchSize(n int) Option {
+	return func(ce *Cache) {
+		ce.commitBatchSize = n
+	}
+}
+
+// WithComparator sets a comparator to order keys in the tree instead of strings.Compare. cmp must return
+// a negative number, zero or a positive number when a is less than, equal to or greater than b.
+// Keys equal by cmp are the same entry in the tree, but pending writes are still distinguished by exact key.
+func WithComparator(cmp func(a, b string) int) Option {
+	return func(ce *Cache) {
+		ce.cmp = cmp
+	}
+}
+
+// WithComparatorCollisionCheck reports a *KeyCollisionError to the error handler when a committed key replaces
+// a different key equal by the comparator. It is intended for debugging lenient comparators.
+func WithComparatorCollisionCheck() Option {
+	return func(ce *Cache) {
+		ce.collisionCheck = true
+	}
+}
+
+// WithErrorHandler sets a handler called for errors occurred in background, like in the queue worker.
+// It shouldn't block.
+func WithErrorHandler(fn func(err error)) Option {
+	return func(ce *Cache) {
+		ce.errorHandler = fn
+	}
+}
+
+// WithTailBuffer sets count of events buffered for a slow Tail subscriber. DefaultTailBuffer is used by default.
+func WithTailBuffer(n int) Option {
+	return func(ce *Cache) {
+		ce.tailBuffer = n
+	}
+}
+
+// WithStats enables collecting statistics of the cache. See Stats.
+func WithStats() Option {
+	return func(ce *Cache) {
+		ce.stats = new(stats)
+	}
+}
+
+// WithDiskTier spills least recently used items to files in dir when count of committed items exceeds maxMemItems.
+// Values are serialized by the ValueCodec given by WithValueCodec, so they must be serializable by it.
+// Get promotes a spilled entry back to memory when it misses. Other methods including iterations and snapshots
+// see only entries in memory. Reading a spilled entry costs a file read and a file removal, so maxMemItems should cover
+// the working set. dir must exist and be dedicated to the cache; existing files aren't loaded.
+func WithDiskTier(dir string, maxMemItems int) Option {
+	return func(ce *Cache) {
+		ce.disk = newDiskTier(dir, maxMemItems)
+		ce.statItems = true
+	}
+}
+
+// WithInterceptor sets an Interceptor called around Get, Set and Del.
+func WithInterceptor(fn Interceptor) Option {
+	return func(ce *Cache) {
+		ce.interceptor = fn
+	}
+}
+
+// WithOperationTimeout makes GetOrSetErr, GetAndSetErr and Sync give up and return ErrTimeout,
+// if they couldn't acquire the locks or the queue couldn't be committed in d. Other methods always block.
+// A timeout opens the circuit for d: meanwhile these methods fail fast with ErrTimeout without waiting,
+// and the first call after d waits again. Waiting for locks sleeps, it doesn't spin.
+func WithOperationTimeout(d time.Duration) Option {
+	return func(ce *Cache) {
+		ce.opTimeout = d
+	}
+}
+
+// WithMemoizeTTL sets the TTL of results cached by Memoize. They are cached forever by default.
+func WithMemoizeTTL(ttl time.Duration) Option {
+	return func(ce *Cache) {
+		ce.memoizeTTL = ttl
+	}
+}
+
+// WithCapacityThreshold calls fn when count of committed items crosses fraction of the limit upward.
+// The limit is given by WithMaxItems, or by WithDiskTier if there is no item limit; fn is never called without a limit.
+// It is called once per crossing, then again only after the usage falls below the threshold.
+// The usage is checked by the queue worker before evicting, and fn is called in a new goroutine.
+func WithCapacityThreshold(fraction float64, fn func(used, limit int64)) Option {
+	return func(ce *Cache) {
+		ce.capThreshold = fraction
+		ce.capFn = fn
+	}
+}
+
+// WithInsertionOrder keeps a secondary index of entries by the order they were first inserted. See RangeByInsertion.
+// The index costs a key and a sequence number per entry, and a tree update per insertion and deletion.
+func WithInsertionOrder() Option {
+	return func(ce *Cache) {
+		ce.ins = btree.New(DefaultDegree)
+	}
+}
+
+// WithSizeFunc sets a func estimates size of a value in bytes. See ValueSizeHistogram.
+func WithSizeFunc(fn func(val interface{}) int64) Option {
+	return func(ce *Cache) {
+		ce.sizeFn = fn
+	}
+}
+
+// WithMaxListLen limits length of slices pushed by PushFront and PushBack. n <= 0 means no limit.
+func WithMaxListLen(n int) Option {
+	return func(ce *Cache) {
+		ce.maxListLen = n
+	}
+}
+
+// WithMaxNamespaces limits count of distinct namespaces have entries to n. The namespace of a key is the part before
+// the first delimiter, or the whole key if it doesn't contain delimiter. Writes create keys reject a key of a new
+// namespace when n namespaces exist. Namespaces of committed entries and pending writes are counted together,
+// so the limit is never exceeded. SetErr, Tx.Set, Once and LoadMerge return ErrTooManyNamespaces, other writes report
+// it to the error handler; see docs of the methods. Snapshots loaded by Load and LoadBinary aren't limited.
+func WithMaxNamespaces(n int, delimiter string) Option {
+	return func(ce *Cache) {
+		ce.maxNamespaces = n
+		ce.nsDelimiter = delimiter
+	}
+}
+
+// WithTransactionRetries sets count of retries of Transaction on conflicts. DefaultTransactionRetries is used by default.
+func WithTransactionRetries(n int) Option {
+	return func(ce *Cache) {
+		ce.txRetries = n
+	}
+}
+
+// WithSignalStrategy sets how writers wake up the queue worker. SignalBestEffort is used by default. See SignalStrategy.
+func WithSignalStrategy(s SignalStrategy) Option {
+	return func(ce *Cache) {
+		ce.signal = s
+	}
+}
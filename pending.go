@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"sort"
+	"sync/atomic"
+)
+
+// PendingKeys returns the keys have writes waiting in the queue in ascending order.
+// Writes the queue worker is already committing aren't included.
+func (ce *Cache) PendingKeys() (keys []string) {
+	ce.quMu.RLock()
+	keys = make([]string, 0, len(ce.qu))
+	for key := range ce.qu {
+		keys = append(keys, key)
+	}
+	ce.quMu.RUnlock()
+	sort.Strings(keys)
+	return
+}
+
+// CancelPending drops the write of given key waiting in the queue, and returns whether it was dropped.
+// It returns false, if the key has no write in the queue, or the queue worker has already taken it to commit.
+// Writes to the same key are coalesced in the queue, so only the latest write is dropped and the key keeps its committed value.
+// A lookup key of SetSorted is mapped like Get, and the mapping is dropped if the entry wasn't committed.
+func (ce *Cache) CancelPending(key string) (ok bool) {
+	ce.quMu.Lock()
+	mapped := false
+	if atomic.LoadInt32(&ce.sortedCount) > 0 {
+		lookupKey := key
+		key = ce.sortedKey(key)
+		mapped = key != lookupKey
+	}
+	var im item
+	if im, ok = ce.qu[key]; ok {
+		if im.Val != nil {
+			ce.queueNamespace(key, -1)
+		}
+		delete(ce.qu, key)
+		if mapped {
+			ce.untrackSorted(key)
+		}
+	}
+	ce.quMu.Unlock()
+	return
+}
@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"encoding/gob"
+	"io"
+
+	"github.com/google/btree"
+)
+
+// Save writes a consistent snapshot of the cache to w as a gob stream of Entry.
+// Types of values must be registered by gob.Register, except basic types.
+func (ce *Cache) Save(w io.Writer) (err error) {
+	enc := gob.NewEncoder(w)
+	ce.snapshot().Ascend(func(i btree.Item) bool {
+		im := i.(item)
+		err = enc.Encode(&Entry{Key: im.Key, Val: im.Val})
+		return err == nil
+	})
+	return
+}
+
+// Load replaces the contents of the cache with the snapshot read from r.
+// The cache isn't changed, if the snapshot couldn't be decoded.
+func (ce *Cache) Load(r io.Reader) (err error) {
+	m, err := decodeEntries(r)
+	if err != nil {
+		return
+	}
+	ce.replace(m)
+	return
+}
+
+// replace replaces the contents of the cache with m.
+func (ce *Cache) replace(m map[string]interface{}) {
+	tr := btree.New(ce.degree)
+	for key, val := range m {
+		tr.ReplaceOrInsert(ce.newTreeItem(key, val))
+	}
+	ce.swap(tr)
+}
+
+// LoadMerge merges the snapshot read from r into the cache without clearing existing entries.
+// If a key already exists, the value is resolved by conflict. If conflict is nil, the incoming value wins.
+// The cache isn't changed, if the snapshot couldn't be decoded. Missing keys rejected by WithMaxNamespaces are skipped,
+// and LoadMerge returns ErrTooManyNamespaces after merging other entries.
+func (ce *Cache) LoadMerge(r io.Reader, conflict func(key string, existing, incoming interface{}) interface{}) (err error) {
+	m, err := decodeEntries(r)
+	if err != nil {
+		return
+	}
+	ce.quMu.Lock()
+	for key, val := range m {
+		if _, ok := ce.present(key); !ok && val != nil {
+			if err2 := ce.checkNamespace(key); err2 != nil {
+				err = err2
+				continue
+			}
+		}
+		if conflict != nil {
+			if im, ok := ce.lookup(key); ok {
+				val = conflict(key, im.Val, val)
+			}
+		}
+		ce.enqueue(ce.newItem(key, val))
+	}
+	ce.quMu.Unlock()
+	ce.notify()
+	return
+}
+
+func decodeEntries(r io.Reader) (m map[string]interface{}, err error) {
+	dec := gob.NewDecoder(r)
+	m = make(map[string]interface{})
+	for {
+		var e Entry
+		if err = dec.Decode(&e); err != nil {
+			if err == io.EOF {
+				err = nil
+				break
+			}
+			m = nil
+			return
+		}
+		if e.Val != nil {
+			m[e.Key] = e.Val
+		}
+	}
+	return
+}
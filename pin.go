@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"sync/atomic"
+)
+
+func (ce *Cache) isPinned(key string) (ok bool) {
+	ce.pinMu.RLock()
+	_, ok = ce.pins[key]
+	ce.pinMu.RUnlock()
+	return
+}
+
+// Pin pins given key. Pinned entries are never evicted by capacity, memory or disk tier limits and never expire,
+// but they can still be deleted explicitly. The pin stays for later writes, until Unpin.
+func (ce *Cache) Pin(key string) {
+	ce.pinMu.Lock()
+	if ce.pins == nil {
+		ce.pins = make(map[string]struct{})
+	}
+	if _, ok := ce.pins[key]; !ok {
+		ce.pins[key] = struct{}{}
+		atomic.AddInt32(&ce.pinCount, 1)
+	}
+	ce.pinMu.Unlock()
+	ce.repin(key, true)
+}
+
+// Unpin unpins given key.
+func (ce *Cache) Unpin(key string) {
+	ce.pinMu.Lock()
+	if _, ok := ce.pins[key]; ok {
+		delete(ce.pins, key)
+		atomic.AddInt32(&ce.pinCount, -1)
+	}
+	ce.pinMu.Unlock()
+	ce.repin(key, false)
+}
+
+// repin rewrites the current item of given key with the pin flag.
+func (ce *Cache) repin(key string, pin bool) {
+	ce.quMu.Lock()
+	im, ok := ce.find(key)
+	if !ok || im.pin == pin {
+		ce.quMu.Unlock()
+		return
+	}
+	im.pin = pin
+	ce.enqueue(im)
+	ce.quMu.Unlock()
+	ce.notify()
+}
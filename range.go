@@ -0,0 +1,140 @@
+package cache
+
+import (
+	"github.com/google/btree"
+)
+
+// ascendRange calls fn for every item of tr in [start, end) in ascending order. If end is empty, the range has no upper bound.
+func (ce *Cache) ascendRange(tr *btree.BTree, start, end string, fn func(im item) bool) {
+	iter := func(i btree.Item) bool {
+		return fn(i.(item))
+	}
+	if end == "" {
+		tr.AscendGreaterOrEqual(ce.probe(start), iter)
+		return
+	}
+	tr.AscendRange(ce.probe(start), ce.probe(end), iter)
+}
+
+// RangeDelete calls fn for every key in [start, end) in ascending order, and deletes the keys fn returns true for.
+// If end is empty, the range has no upper bound. The walk is done on a consistent snapshot and the keys are deleted after
+// the walk completes, so fn can safely use the cache. A key written after the snapshot, even by fn, isn't deleted,
+// so the write isn't lost. It returns count of deleted keys.
+func (ce *Cache) RangeDelete(start, end string, fn func(key string, val interface{}) (delete bool)) (count int) {
+	var items []item
+	ce.ascendRange(ce.snapshot(), start, end, func(im item) bool {
+		if fn(im.Key, im.Val) {
+			items = append(items, im)
+		}
+		return true
+	})
+	if len(items) == 0 {
+		return
+	}
+	ce.quMu.Lock()
+	for _, im := range items {
+		if cur, ok := ce.find(im.Key); ok && cur.ws == im.ws {
+			ce.enqueue(ce.newItem(im.Key, nil))
+			count++
+		}
+	}
+	ce.quMu.Unlock()
+	if count > 0 {
+		ce.notify()
+	}
+	return
+}
+
+// PruneRange deletes every key less than end, and every key not less than end pred returns true for, in one atomic pass.
+// If pred is nil, the pass stops at end. pred is called while the queue is locked, so it mustn't use the cache.
+// It returns count of deleted keys.
+func (ce *Cache) PruneRange(end string, pred func(key string, val interface{}) bool) (count int) {
+	ce.quMu.Lock()
+	var keys []string
+	tr := ce.snapshotLocked()
+	iter := func(i btree.Item) bool {
+		if im := i.(item); im.visible() {
+			keys = append(keys, im.Key)
+		}
+		return true
+	}
+	tr.AscendLessThan(ce.probe(end), iter)
+	if pred != nil {
+		ce.ascendRange(tr, end, "", func(im item) bool {
+			if im.visible() && pred(im.Key, im.Val) {
+				keys = append(keys, im.Key)
+			}
+			return true
+		})
+	}
+	for _, key := range keys {
+		ce.enqueue(ce.newItem(key, nil))
+	}
+	ce.quMu.Unlock()
+	if len(keys) > 0 {
+		ce.notify()
+	}
+	count = len(keys)
+	return
+}
+
+// RangeAscending calls fn for every entry in ascending key order until fn returns false.
+// The walk is done on a consistent snapshot, so fn can safely use the cache.
+func (ce *Cache) RangeAscending(fn func(key string, val interface{}) bool) {
+	ce.snapshot().Ascend(func(i btree.Item) bool {
+		im := i.(item)
+		return fn(im.Key, im.Val)
+	})
+}
+
+// RangeDescending calls fn for every entry in descending key order until fn returns false.
+// The walk is done on a consistent snapshot, so fn can safely use the cache.
+func (ce *Cache) RangeDescending(fn func(key string, val interface{}) bool) {
+	ce.snapshot().Descend(func(i btree.Item) bool {
+		im := i.(item)
+		return fn(im.Key, im.Val)
+	})
+}
+
+// Range calls fn for every entry until fn returns false. Entries are in ascending key order,
+// or descending if WithDefaultDescending option is given.
+func (ce *Cache) Range(fn func(key string, val interface{}) bool) {
+	if ce.descending {
+		ce.RangeDescending(fn)
+		return
+	}
+	ce.RangeAscending(fn)
+}
+
+// Keys returns all keys in the order of Range.
+func (ce *Cache) Keys() (keys []string) {
+	ce.Range(func(key string, val interface{}) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return
+}
+
+// All returns all entries in the order of Range.
+func (ce *Cache) All() (entries []Entry) {
+	ce.Range(func(key string, val interface{}) bool {
+		entries = append(entries, Entry{Key: key, Val: val})
+		return true
+	})
+	return
+}
+
+// GroupBy buckets entries of a consistent snapshot by keyFn, and calls fn for every group.
+// Groups are passed in unspecified order, but entries in a group are in ascending key order.
+func (ce *Cache) GroupBy(keyFn func(key string, val interface{}) string, fn func(group string, entries []Entry)) {
+	groups := make(map[string][]Entry)
+	ce.snapshot().Ascend(func(i btree.Item) bool {
+		im := i.(item)
+		group := keyFn(im.Key, im.Val)
+		groups[group] = append(groups[group], Entry{Key: im.Key, Val: im.Val})
+		return true
+	})
+	for group, entries := range groups {
+		fn(group, entries)
+	}
+}
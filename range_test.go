@@ -0,0 +1,33 @@
+package cache
+
+import (
+	"testing"
+)
+
+func TestRangeDeleteKeepsLaterWrites(t *testing.T) {
+	ce := NewCache()
+	defer ce.Close()
+	ce.Set("a", 1)
+	ce.Set("b", 2)
+	ce.Set("c", 3)
+	ce.Sync()
+	count := ce.RangeDelete("", "", func(key string, val interface{}) bool {
+		if key == "a" {
+			ce.Set("a", 10)
+		}
+		return key != "c"
+	})
+	if count != 1 {
+		t.Errorf("got %d deleted, want 1", count)
+	}
+	ce.Sync()
+	if val := ce.Get("a"); val != 10 {
+		t.Errorf("got %v, want the later write 10", val)
+	}
+	if val := ce.Get("b"); val != nil {
+		t.Errorf("got %v, want b deleted", val)
+	}
+	if val := ce.Get("c"); val != 3 {
+		t.Errorf("got %v, want c kept", val)
+	}
+}
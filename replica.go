@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/google/btree"
+)
+
+// NewReplica returns a new Cache mirrors the cache asynchronously by its Tail stream. Reads of the replica don't
+// contend with locks of the primary. The replica is eventually consistent: committed changes of the primary are applied
+// with a lag, and it is resynced from a snapshot when the stream has a gap. Expiry times aren't mirrored, but expired
+// entries are deleted by the primary's events. The replica must be treated as read-only, writes must go to the primary,
+// otherwise they are overwritten or lost. Mirroring stops when the replica or the primary is closed.
+func (ce *Cache) NewReplica() (rc *Cache) {
+	var opts []Option
+	if ce.cmp != nil {
+		opts = append(opts, WithComparator(ce.cmp))
+	}
+	rc = NewCacheDegree(ce.degree, opts...)
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := ce.Tail(ctx)
+	rc.replace(ce.replicaSnapshot())
+	go func() {
+		defer cancel()
+		for {
+			var e Event
+			var ok bool
+			select {
+			case e, ok = <-ch:
+			case <-rc.done:
+				return
+			}
+			if !ok {
+				return
+			}
+			switch {
+			case e.Gap:
+				rc.replace(ce.replicaSnapshot())
+			case e.Val == nil:
+				rc.Del(e.Key)
+			default:
+				rc.Set(e.Key, e.Val)
+			}
+		}
+	}()
+	return
+}
+
+// replicaSnapshot returns visible entries of a snapshot to resync a replica.
+func (ce *Cache) replicaSnapshot() (m map[string]interface{}) {
+	tr := ce.snapshot()
+	m = make(map[string]interface{}, tr.Len())
+	tr.Ascend(func(i btree.Item) bool {
+		if im := i.(item); im.visible() {
+			m[im.Key] = im.Val
+		}
+		return true
+	})
+	return
+}
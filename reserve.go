@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// reservation is the placeholder value of a reserved key.
+type reservation struct {
+	token uint64
+}
+
+// isReservation reports whether val is the placeholder of a reserved key.
+func isReservation(val interface{}) bool {
+	_, ok := val.(reservation)
+	return ok
+}
+
+// Reserve reserves given key by a placeholder, if the key wasn't exist or reserved. It returns a token to Commit the value.
+// ok is false, if the key was already exist or reserved, or it is rejected by WithMaxNamespaces and the error is
+// reported to the error handler. Reserved keys are not visible to reads like Get until committed.
+// Writes create missing keys like GetOrSet, SetIf, Upsert, MergeMap, IncMulti, IncDetailed, PushFront and PushBack
+// treat reserved keys as existing and leave them untouched, Memoize and Once wait for them like Await, and eviction
+// skips them. Explicit writes like Set and Del replace them, so a commit with a stale token fails.
+func (ce *Cache) Reserve(key string) (token uint64, ok bool) {
+	ce.quMu.Lock()
+	if _, exists := ce.find(key); exists {
+		ce.quMu.Unlock()
+		return
+	}
+	if err := ce.checkNamespace(key); err != nil {
+		ce.quMu.Unlock()
+		ce.error(err)
+		return
+	}
+	token = atomic.AddUint64(&ce.tokenSeq, 1)
+	atomic.StoreInt32(&ce.hidden, 1)
+	ce.enqueue(ce.newItem(key, reservation{token: token}))
+	ce.quMu.Unlock()
+	ce.notify()
+	ok = true
+	return
+}
+
+// Commit replaces the placeholder of given key with val, if the key is still reserved by token. It returns whether it was committed.
+func (ce *Cache) Commit(key string, token uint64, val interface{}) (ok bool) {
+	ce.quMu.Lock()
+	im, exists := ce.find(key)
+	if r, isReserved := im.Val.(reservation); !exists || !isReserved || r.token != token {
+		ce.quMu.Unlock()
+		return
+	}
+	ce.enqueue(ce.newItem(key, val))
+	ce.quMu.Unlock()
+	ce.notify()
+	ok = true
+	return
+}
+
+// Cancel deletes the placeholder of given key, if the key is still reserved by token. It returns whether it was cancelled.
+func (ce *Cache) Cancel(key string, token uint64) (ok bool) {
+	ce.quMu.Lock()
+	im, exists := ce.find(key)
+	if r, isReserved := im.Val.(reservation); !exists || !isReserved || r.token != token {
+		ce.quMu.Unlock()
+		return
+	}
+	ce.enqueue(ce.newItem(key, nil))
+	ce.quMu.Unlock()
+	ce.notify()
+	ok = true
+	return
+}
+
+// Await returns the value of given key. If the key is reserved, it waits until the reservation is committed or cancelled.
+// It returns false, if the key wasn't exist, the reservation was cancelled or overwritten by a delete,
+// or ctx is done or the cache is closed while waiting.
+func (ce *Cache) Await(ctx context.Context, key string) (val interface{}, ok bool) {
+	for {
+		ce.quMu.RLock()
+		im, exists := ce.find(key)
+		seqCh := ce.seqCh
+		ce.quMu.RUnlock()
+		if !exists {
+			return
+		}
+		if _, isReserved := im.Val.(reservation); !isReserved {
+			if ok = im.visible(); ok {
+				val = im.Val
+			}
+			return
+		}
+		select {
+		case <-seqCh:
+		case <-ctx.Done():
+			return
+		case <-ce.done:
+			return
+		}
+	}
+}
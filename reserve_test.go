@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestReservationIsPresent(t *testing.T) {
+	ce := NewCache()
+	defer ce.Close()
+	tok, ok := ce.Reserve("k")
+	if !ok {
+		t.Fatal("couldn't reserve")
+	}
+	if val, found := ce.GetOrSet("k", "other"); val != nil || !found {
+		t.Errorf("GetOrSet: got %v, %v", val, found)
+	}
+	if ce.SetIf("k", "other", func(interface{}, bool) bool { return true }) {
+		t.Error("SetIf wrote a reserved key")
+	}
+	if ce.Upsert("k", "other", 1) {
+		t.Error("Upsert wrote a reserved key")
+	}
+	ce.MergeMap("k", map[string]int{"a": 1})
+	if vals := ce.IncMulti(map[string]int64{"k": 1}); len(vals) != 0 {
+		t.Errorf("IncMulti: got %v", vals)
+	}
+	if val, created := ce.IncDetailed("k", 1); val != 0 || created {
+		t.Errorf("IncDetailed: got %v, %v", val, created)
+	}
+	if n := ce.PushBack("k", 1); n != 0 {
+		t.Errorf("PushBack: got %v", n)
+	}
+	if ce.Transaction("k", func(interface{}, bool) (interface{}, bool) { return "other", true }) {
+		t.Error("Transaction wrote a reserved key")
+	}
+	if !ce.Commit("k", tok, "mine") {
+		t.Fatal("Commit failed")
+	}
+	if val := ce.Get("k"); val != "mine" {
+		t.Fatalf("got %v, want mine", val)
+	}
+}
+
+func TestMemoizeWaitsForReservation(t *testing.T) {
+	ce := NewCache()
+	defer ce.Close()
+	tok, _ := ce.Reserve("k")
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		ce.Commit("k", tok, "mine")
+	}()
+	if val := ce.Memoize("k", func() interface{} { return "other" }); val != "mine" {
+		t.Fatalf("Memoize: got %v, want mine", val)
+	}
+	if val, err := ce.Once("k", func() (interface{}, error) { return "other", nil }); val != "mine" || err != nil {
+		t.Fatalf("Once: got %v, %v", val, err)
+	}
+}
+
+func TestEvictionSkipsReservations(t *testing.T) {
+	ce := NewCache(WithMaxItems(1))
+	defer ce.Close()
+	tok, _ := ce.Reserve("a")
+	ce.Sync()
+	ce.Set("b", 1)
+	ce.Set("c", 2)
+	ce.Sync()
+	if !ce.Commit("a", tok, "mine") {
+		t.Fatal("the reservation was evicted")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if val, ok := ce.Await(ctx, "a"); !ok || val != "mine" {
+		t.Fatalf("got %v, %v", val, ok)
+	}
+}
+
+func TestReservationEvents(t *testing.T) {
+	ce := NewCache()
+	defer ce.Close()
+	ch, stop := ce.Watch()
+	defer stop()
+	tok, _ := ce.Reserve("k")
+	ce.Sync()
+	ce.Commit("k", tok, "v")
+	ce.Sync()
+	for _, want := range []interface{}{nil, "v"} {
+		select {
+		case e := <-ch:
+			if e.Key != "k" || e.Val != want {
+				t.Errorf("got %+v, want value %v", e, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("no event")
+		}
+	}
+}
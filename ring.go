@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	// RingReplicas is count of virtual nodes per unit of weight of a cache on Ring.
+	RingReplicas = 64
+)
+
+type ringNode struct {
+	hash uint64
+	ce   *Cache
+}
+
+// Ring routes keys to member caches by consistent hashing with virtual nodes.
+// Adding or removing a member moves only the keys of that member. The zero value is an empty ring.
+// All methods of Ring struct are concurrency safe.
+type Ring struct {
+	mu    sync.RWMutex
+	nodes []ringNode
+}
+
+// ringHash returns a well-mixed 64-bit hash of s.
+func ringHash(s string) uint64 {
+	h := sketchHash(s)
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+// Add adds the cache to the ring with weight*RingReplicas virtual nodes. Adding an existing cache replaces its weight.
+func (r *Ring) Add(ce *Cache, weight int) {
+	r.mu.Lock()
+	nodes := r.without(ce)
+	for i := 0; i < weight*RingReplicas; i++ {
+		nodes = append(nodes, ringNode{hash: ringHash(fmt.Sprintf("%p-%d", ce, i)), ce: ce})
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].hash < nodes[j].hash
+	})
+	r.nodes = nodes
+	r.mu.Unlock()
+}
+
+// Remove removes the cache from the ring.
+func (r *Ring) Remove(ce *Cache) {
+	r.mu.Lock()
+	r.nodes = r.without(ce)
+	r.mu.Unlock()
+}
+
+// without returns a copy of nodes except nodes of the cache. It must be called while mu is locked.
+func (r *Ring) without(ce *Cache) (nodes []ringNode) {
+	nodes = make([]ringNode, 0, len(r.nodes))
+	for _, n := range r.nodes {
+		if n.ce != ce {
+			nodes = append(nodes, n)
+		}
+	}
+	return
+}
+
+// Get returns the member cache of given key. It returns nil, if the ring is empty.
+func (r *Ring) Get(key string) (ce *Cache) {
+	h := ringHash(key)
+	r.mu.RLock()
+	if len(r.nodes) > 0 {
+		i := sort.Search(len(r.nodes), func(i int) bool {
+			return r.nodes[i].hash >= h
+		})
+		if i >= len(r.nodes) {
+			i = 0
+		}
+		ce = r.nodes[i].ce
+	}
+	r.mu.RUnlock()
+	return
+}
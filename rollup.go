@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"strings"
+	"time"
+)
+
+// Rollup aggregates the values of keys have srcPrefix and were written within window before now by agg,
+// stores the result at dstKey, and returns it. If window isn't positive, all keys have srcPrefix are aggregated.
+// agg is called with the values in ascending key order, and it isn't called if there is no value.
+// The read of sources and the write of the result are atomic, because the queue is locked while rolling up,
+// so agg mustn't use the cache. dstKey isn't included in sources even if it has srcPrefix.
+// If dstKey is rejected by WithMaxNamespaces, nothing is written, it returns nil and the error is reported to the error handler.
+func (ce *Cache) Rollup(srcPrefix, dstKey string, window time.Duration, agg func(vals []interface{}) interface{}) interface{} {
+	return ce.rollup(srcPrefix, dstKey, window, agg, false)
+}
+
+// RollupDelete is like Rollup, but it deletes the aggregated sources in the same atomic step.
+func (ce *Cache) RollupDelete(srcPrefix, dstKey string, window time.Duration, agg func(vals []interface{}) interface{}) interface{} {
+	return ce.rollup(srcPrefix, dstKey, window, agg, true)
+}
+
+func (ce *Cache) rollup(srcPrefix, dstKey string, window time.Duration, agg func(vals []interface{}) interface{}, del bool) (result interface{}) {
+	var since int64
+	if window > 0 {
+		since = time.Now().Add(-window).UnixNano()
+	}
+	ce.quMu.Lock()
+	var keys []string
+	var vals []interface{}
+	ce.ascendRange(ce.snapshotLocked(), srcPrefix, "", func(im item) bool {
+		if !strings.HasPrefix(im.Key, srcPrefix) {
+			return false
+		}
+		if im.Key != dstKey && im.visible() && im.wt >= since {
+			keys = append(keys, im.Key)
+			vals = append(vals, im.Val)
+		}
+		return true
+	})
+	if len(vals) == 0 {
+		ce.quMu.Unlock()
+		return
+	}
+	if _, ok := ce.present(dstKey); !ok {
+		if err := ce.checkNamespace(dstKey); err != nil {
+			ce.quMu.Unlock()
+			ce.error(err)
+			return
+		}
+	}
+	result = agg(vals)
+	if del {
+		for _, key := range keys {
+			ce.enqueue(ce.newItem(key, nil))
+		}
+	}
+	ce.enqueue(ce.newItem(dstKey, result))
+	ce.quMu.Unlock()
+	ce.notify()
+	return
+}
@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"sync/atomic"
+)
+
+// SignalStrategy is the way writers wake up the queue worker. See WithSignalStrategy.
+// Every strategy guarantees that a write is committed after the signal of it, no signal is lost.
+// BenchmarkSignalStrategy compares them under low and high write rates.
+type SignalStrategy int
+
+const (
+	// SignalBestEffort sends a signal to a buffered channel for every write, and drops it if the buffer is full.
+	// A full buffer means the worker has pending signals, so it is woken up again. The worker wakes up immediately,
+	// but it may wake up many times for writes already committed.
+	SignalBestEffort SignalStrategy = iota
+
+	// SignalCoalesced sends a signal to a single slot channel only if a dirty flag wasn't set. The worker clears
+	// the flag before draining the queue. The worker wakes up immediately, and at most once for writes enqueued
+	// before it drains the queue, and writers avoid channel sends while the worker is behind.
+	SignalCoalesced
+
+	// SignalCond wakes up the worker by a condition variable. Writers take a mutex for every signal,
+	// so it is fair but it costs more under high write rates. The worker wakes up after the goroutine scheduling.
+	SignalCond
+)
+
+// notify wakes up the queue worker if it is not already awake.
+func (ce *Cache) notify() {
+	switch ce.signal {
+	case SignalCoalesced:
+		if !atomic.CompareAndSwapInt32(&ce.dirty, 0, 1) {
+			return
+		}
+	case SignalCond:
+		ce.sigMu.Lock()
+		ce.sigSet = true
+		ce.cond.Signal()
+		ce.sigMu.Unlock()
+		return
+	}
+	select {
+	case ce.quCh <- struct{}{}:
+	default:
+	}
+}
+
+// wait waits for a signal of writers. It returns false, if the cache is closed.
+func (ce *Cache) wait() bool {
+	if ce.signal == SignalCond {
+		ce.sigMu.Lock()
+		for !ce.sigSet && !ce.closed() {
+			ce.cond.Wait()
+		}
+		ce.sigSet = false
+		ce.sigMu.Unlock()
+		return !ce.closed()
+	}
+	select {
+	case <-ce.done:
+		return false
+	case <-ce.quCh:
+	}
+	if ce.signal == SignalCoalesced {
+		atomic.StoreInt32(&ce.dirty, 0)
+	}
+	return true
+}
+
+func (ce *Cache) closed() bool {
+	select {
+	case <-ce.done:
+		return true
+	default:
+		return false
+	}
+}
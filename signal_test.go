@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"strconv"
+	"testing"
+)
+
+func BenchmarkSignalStrategy(b *testing.B) {
+	const keys = 1 << 10
+	names := make([]string, keys)
+	for i := range names {
+		names[i] = strconv.Itoa(i)
+	}
+	strategies := []struct {
+		name string
+		s    SignalStrategy
+	}{
+		{"besteffort", SignalBestEffort},
+		{"coalesced", SignalCoalesced},
+		{"cond", SignalCond},
+	}
+	for _, st := range strategies {
+		// low write rate: the worker is idle before every write, so it measures the wake up latency.
+		b.Run(st.name+"/low", func(b *testing.B) {
+			ce := NewCache(WithSignalStrategy(st.s))
+			defer ce.Close()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				ce.Set(names[i&(keys-1)], i)
+				if err := ce.Sync(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		// high write rate: writers never wait, so it measures the cost of signals while the worker is behind.
+		b.Run(st.name+"/high", func(b *testing.B) {
+			ce := NewCache(WithSignalStrategy(st.s))
+			defer ce.Close()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					ce.Set(names[i&(keys-1)], i)
+					i++
+				}
+			})
+			b.StopTimer()
+			ce.Sync()
+		})
+	}
+}
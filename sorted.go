@@ -0,0 +1,146 @@
+package cache
+
+import (
+	"sync/atomic"
+)
+
+// sortedRef is the key in the tree of a lookup key of SetSorted, and the write sequence of the latest write to it.
+type sortedRef struct {
+	key string
+	ws  uint64
+}
+
+// sortedKey returns the key in the tree of given lookup key of SetSorted, or key itself if it wasn't set by SetSorted.
+func (ce *Cache) sortedKey(key string) string {
+	ce.sortedMu.RLock()
+	if ref, ok := ce.sorted[key]; ok {
+		key = ref.key
+	}
+	ce.sortedMu.RUnlock()
+	return key
+}
+
+// mapSorted maps lookupKey to key in the tree.
+func (ce *Cache) mapSorted(lookupKey, key string) {
+	ce.sortedMu.Lock()
+	if ce.sorted == nil {
+		ce.sorted = make(map[string]sortedRef)
+	}
+	ref, ok := ce.sorted[lookupKey]
+	if !ok {
+		atomic.AddInt32(&ce.sortedCount, 1)
+	}
+	if ref.key != key {
+		ref = sortedRef{key: key}
+	}
+	ce.sorted[lookupKey] = ref
+	ce.sortedMu.Unlock()
+}
+
+// unmapSorted drops the mapping to given key in the tree, if no write to the key is newer than the write sequence ws.
+func (ce *Cache) unmapSorted(key string, ws uint64) {
+	parts := SplitKey(key)
+	if len(parts) != 2 {
+		return
+	}
+	ce.sortedMu.Lock()
+	if ref, ok := ce.sorted[parts[1]]; ok && ref.key == key && ref.ws <= ws {
+		delete(ce.sorted, parts[1])
+		atomic.AddInt32(&ce.sortedCount, -1)
+	}
+	ce.sortedMu.Unlock()
+}
+
+// trackSorted records the enqueued item as the latest write of its mapping, or drops the mapping if the item deletes it.
+// It must be called while quMu is locked.
+func (ce *Cache) trackSorted(im item) {
+	if im.Val == nil {
+		ce.unmapSorted(im.Key, im.ws)
+		return
+	}
+	parts := SplitKey(im.Key)
+	if len(parts) != 2 {
+		return
+	}
+	ce.sortedMu.Lock()
+	if ref, ok := ce.sorted[parts[1]]; ok && ref.key == im.Key {
+		ref.ws = im.ws
+		ce.sorted[parts[1]] = ref
+	}
+	ce.sortedMu.Unlock()
+}
+
+// untrackSorted points the mapping to given key in the tree at the latest write except the queue, after the write in the queue
+// was dropped. The mapping is dropped, if the key wasn't committed or it is being deleted. It must be called while quMu is locked.
+func (ce *Cache) untrackSorted(key string) {
+	im, ok := ce.cm[key]
+	if !ok {
+		ce.trMu.RLock()
+		if r := ce.tr.Get(ce.probe(key)); r != nil {
+			im, ok = r.(item), true
+		}
+		ce.trMu.RUnlock()
+	}
+	if ok && im.Val != nil {
+		ce.trackSorted(im)
+		return
+	}
+	ce.unmapSorted(key, ^uint64(0))
+}
+
+// SetSorted sets the value of lookupKey, but stores the entry in the tree ordered by sortKey and then by lookupKey.
+// The key in the tree is CompositeKey(sortKey, lookupKey), and it is mapped from lookupKey by a hash map, so single key
+// methods like Get, Del, GetOrSet and Touch work on the entry by lookupKey. Overwriting with another sortKey deletes
+// the entry at the old position and inserts it at the new one atomically. If val is nil, the entry is deleted.
+// The mapping is dropped when the entry is deleted, evicted or expired, and it isn't restored by Load or LoadBinary.
+// WithMaxNamespaces applies to the key in the tree; a rejected write is reported to the error handler. See RangeSorted.
+func (ce *Cache) SetSorted(lookupKey string, sortKey string, val interface{}) {
+	key := CompositeKey(sortKey, lookupKey)
+	ce.quMu.Lock()
+	if val != nil {
+		if err := ce.checkNamespace(key); err != nil {
+			ce.quMu.Unlock()
+			ce.error(err)
+			return
+		}
+	}
+	if old := ce.sortedKey(lookupKey); old != lookupKey && old != key {
+		ce.enqueue(ce.newTreeItem(old, nil))
+	}
+	if val != nil {
+		ce.mapSorted(lookupKey, key)
+	}
+	ce.enqueue(ce.newTreeItem(key, val))
+	ce.quMu.Unlock()
+	ce.notify()
+}
+
+// DelSorted deletes the entry of lookupKey set by SetSorted. It is like Del, but it doesn't delete lookupKey
+// if it isn't mapped.
+func (ce *Cache) DelSorted(lookupKey string) {
+	ce.quMu.Lock()
+	key := ce.sortedKey(lookupKey)
+	if key == lookupKey {
+		ce.quMu.Unlock()
+		return
+	}
+	ce.enqueue(ce.newTreeItem(key, nil))
+	ce.quMu.Unlock()
+	ce.notify()
+}
+
+// RangeSorted calls fn for every entry set by SetSorted has sort key in [start, end) in ascending order of sort keys,
+// until fn returns false. If end is empty, the range has no upper bound.
+// The walk is done on a consistent snapshot, so fn can safely use the cache.
+func (ce *Cache) RangeSorted(start, end string, fn func(lookupKey, sortKey string, val interface{}) bool) {
+	if end != "" {
+		end = CompositeKey(end)
+	}
+	ce.ascendRange(ce.snapshot(), CompositeKey(start), end, func(im item) bool {
+		parts := SplitKey(im.Key)
+		if len(parts) != 2 || !im.visible() {
+			return true
+		}
+		return fn(parts[1], parts[0], im.Val)
+	})
+}
@@ -0,0 +1,131 @@
+package cache
+
+import (
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSetSortedOrder(t *testing.T) {
+	ce := NewCache()
+	defer ce.Close()
+	ce.SetSorted("u1", "2024-03", 1)
+	ce.SetSorted("u2", "2024-01", 2)
+	ce.SetSorted("u3", "2024-02", 3)
+	ce.SetSorted("u1", "2023-12", 4)
+	if val := ce.Get("u1"); val != 4 {
+		t.Fatalf("got %v, want 4", val)
+	}
+	if err := ce.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	ce.RangeSorted("", "", func(lookupKey, sortKey string, val interface{}) bool {
+		got = append(got, lookupKey)
+		return true
+	})
+	if len(got) != 3 || got[0] != "u1" || got[1] != "u2" || got[2] != "u3" {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestSortedSingleKeyMethods(t *testing.T) {
+	ce := NewCache()
+	defer ce.Close()
+	ce.SetSorted("u1", "b", 1)
+	if val, found := ce.GetOrSet("u1", 2); val != 1 || !found {
+		t.Errorf("GetOrSet: got %v, %v", val, found)
+	}
+	ce.Set("u1", 3)
+	if err := ce.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if keys := ce.Keys(); len(keys) != 1 || keys[0] != CompositeKey("b", "u1") {
+		t.Errorf("Set wrote another entry: %q", keys)
+	}
+	if !ce.Touch("u1", time.Hour) {
+		t.Error("Touch didn't find the entry")
+	}
+	ce.Del("u1")
+	if val := ce.Get("u1"); val != nil {
+		t.Errorf("Del left %v", val)
+	}
+	if err := ce.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if keys := ce.Keys(); len(keys) != 0 {
+		t.Errorf("Del left %q", keys)
+	}
+	if n := atomic.LoadInt32(&ce.sortedCount); n != 0 {
+		t.Errorf("Del left %d mappings", n)
+	}
+}
+
+func TestSortedMappingDropped(t *testing.T) {
+	waitUnmapped := func(t *testing.T, ce *Cache, want int32) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for atomic.LoadInt32(&ce.sortedCount) != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("got %d mappings, want %d", atomic.LoadInt32(&ce.sortedCount), want)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	t.Run("evict", func(t *testing.T) {
+		ce := NewCache(WithMaxItems(1))
+		defer ce.Close()
+		ce.SetSorted("u1", "a", 1)
+		ce.Sync()
+		ce.SetSorted("u2", "b", 2)
+		ce.Sync()
+		waitUnmapped(t, ce, 1)
+	})
+	t.Run("expire", func(t *testing.T) {
+		ce := NewCache()
+		defer ce.Close()
+		ce.SetSorted("u1", "a", 1)
+		ce.Touch("u1", 10*time.Millisecond)
+		waitUnmapped(t, ce, 0)
+	})
+	t.Run("namespace", func(t *testing.T) {
+		ce := NewCache()
+		defer ce.Close()
+		ce.SetSorted("u1", "a", 1)
+		ce.SetSorted("u2", "b", 2)
+		ce.Sync()
+		ce.DelNamespace(CompositeKey("a"))
+		waitUnmapped(t, ce, 1)
+		if val := ce.Get("u2"); val != 2 {
+			t.Errorf("got %v, want 2", val)
+		}
+	})
+	t.Run("cancel", func(t *testing.T) {
+		ce := NewCache()
+		defer ce.Close()
+		cancelled := int32(0)
+		for i := 0; i < 100; i++ {
+			key := strconv.Itoa(i)
+			ce.SetSorted(key, "a", i)
+			if ce.CancelPending(key) {
+				cancelled++
+				if val := ce.Get(key); val != nil {
+					t.Errorf("cancelled key %q has %v", key, val)
+				}
+			}
+		}
+		ce.Sync()
+		waitUnmapped(t, ce, 100-cancelled)
+	})
+}
+
+func TestSortedGetEntry(t *testing.T) {
+	ce := NewCache()
+	defer ce.Close()
+	ce.SetSorted("u1", "2024", 1)
+	e, ok := ce.GetEntry("u1")
+	if !ok || e.Key != "u1" || e.Val != 1 {
+		t.Errorf("got %+v, %v", e, ok)
+	}
+}
@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"math"
+	"sort"
+	"sync/atomic"
+
+	"github.com/google/btree"
+)
+
+type stats struct {
+	writes  uint64
+	commits uint64
+}
+
+// Stats is statistics of the cache.
+type Stats struct {
+	// Writes is count of writes enqueued.
+	Writes uint64
+
+	// Commits is count of items committed to the tree by the queue worker.
+	// Writes to the same key before the worker drains the queue are coalesced into one commit.
+	Commits uint64
+}
+
+// CoalescingRatio returns ratio of enqueued writes to committed items. 1 means no writes were coalesced.
+// It returns 0, if nothing was committed.
+func (s Stats) CoalescingRatio() float64 {
+	if s.Commits == 0 {
+		return 0
+	}
+	return float64(s.Writes) / float64(s.Commits)
+}
+
+// Stats returns statistics of the cache. It requires WithStats option, otherwise returns zero Stats.
+func (ce *Cache) Stats() (s Stats) {
+	if ce.stats == nil {
+		return
+	}
+	s.Writes = atomic.LoadUint64(&ce.stats.writes)
+	s.Commits = atomic.LoadUint64(&ce.stats.commits)
+	return
+}
+
+// ValueSizeHistogram returns count of entries for every bucket by sizes of values estimated by the size func.
+// buckets are inclusive upper bounds, and an entry is counted in the smallest bucket not less than its size.
+// Entries larger than all buckets are counted in math.MaxInt64. It requires WithStats and WithSizeFunc options,
+// otherwise returns nil. It takes a snapshot and sizes every value, so it is O(n) and meant for periodic diagnostics.
+func (ce *Cache) ValueSizeHistogram(buckets []int64) (hist map[int64]int) {
+	if ce.stats == nil || ce.sizeFn == nil {
+		return
+	}
+	bounds := append([]int64(nil), buckets...)
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i] < bounds[j] })
+	hist = make(map[int64]int, len(bounds)+1)
+	for _, b := range bounds {
+		hist[b] = 0
+	}
+	ce.snapshot().Ascend(func(i btree.Item) bool {
+		im := i.(item)
+		if !im.visible() {
+			return true
+		}
+		size := ce.sizeFn(im.Val)
+		if k := sort.Search(len(bounds), func(k int) bool { return bounds[k] >= size }); k < len(bounds) {
+			hist[bounds[k]]++
+		} else {
+			hist[math.MaxInt64]++
+		}
+		return true
+	})
+	return
+}
@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"context"
+	"sync"
+)
+
+var (
+	// DefaultTailBuffer is default count of events buffered for a slow Tail subscriber.
+	DefaultTailBuffer = 1 << 12
+)
+
+type tailer struct {
+	mu      sync.Mutex
+	pending []Event
+	gap     bool
+	size    int
+	wake    chan struct{}
+}
+
+// push appends the event to pending events. It drops pending events and marks a gap, if the buffer is full.
+func (t *tailer) push(e Event) {
+	t.mu.Lock()
+	if len(t.pending) >= t.size {
+		t.pending = nil
+		t.gap = true
+	} else {
+		t.pending = append(t.pending, e)
+	}
+	t.mu.Unlock()
+	t.notify()
+}
+
+// lose drops pending events and marks a gap.
+func (t *tailer) lose() {
+	t.mu.Lock()
+	t.pending = nil
+	t.gap = true
+	t.mu.Unlock()
+	t.notify()
+}
+
+func (t *tailer) notify() {
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Tail returns a channel delivers every committed change in commit order from the moment of subscription.
+// Unlike Watch, events aren't silently dropped: up to the buffer given by WithTailBuffer, DefaultTailBuffer by default,
+// events wait for the subscriber. If the subscriber falls behind more than the buffer, or the cache is flushed or
+// replaced, buffered events are dropped and an event with Gap true is delivered, and the subscriber should resync.
+// The channel is closed when ctx is done or the cache is closed.
+func (ce *Cache) Tail(ctx context.Context) <-chan Event {
+	t := &tailer{
+		size: ce.tailBuffer,
+		wake: make(chan struct{}, 1),
+	}
+	ce.wMu.Lock()
+	if ce.tailers == nil {
+		ce.tailers = make(map[*tailer]struct{})
+	}
+	ce.tailers[t] = struct{}{}
+	ce.wMu.Unlock()
+	ch := make(chan Event)
+	go func() {
+		defer func() {
+			ce.wMu.Lock()
+			delete(ce.tailers, t)
+			ce.wMu.Unlock()
+			close(ch)
+		}()
+		for {
+			t.mu.Lock()
+			events, gap := t.pending, t.gap
+			t.pending, t.gap = nil, false
+			t.mu.Unlock()
+			if gap {
+				events = append([]Event{{Gap: true}}, events...)
+			}
+			for _, e := range events {
+				select {
+				case ch <- e:
+				case <-ctx.Done():
+					return
+				case <-ce.done:
+					return
+				}
+			}
+			if len(events) > 0 {
+				continue
+			}
+			select {
+			case <-t.wake:
+			case <-ctx.Done():
+				return
+			case <-ce.done:
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// tailGap marks a gap for every Tail subscriber. It must be called while trMu is locked.
+func (ce *Cache) tailGap() {
+	ce.wMu.RLock()
+	for t := range ce.tailers {
+		t.lose()
+	}
+	ce.wMu.RUnlock()
+}
@@ -0,0 +1,31 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTailOrderWithExpiry(t *testing.T) {
+	ce := NewCache()
+	defer ce.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := ce.Tail(ctx)
+	ce.SetTTL("k", 1, 10*time.Millisecond)
+	ce.Sync()
+	time.Sleep(50 * time.Millisecond)
+	ce.Set("k", 2)
+	ce.Sync()
+	want := []interface{}{1, nil, 2}
+	for i, val := range want {
+		select {
+		case e := <-ch:
+			if e.Gap || e.Key != "k" || e.Val != val {
+				t.Fatalf("event %d: got %+v, want value %v", i, e, val)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("event %d: timed out", i)
+		}
+	}
+}
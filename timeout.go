@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// opBegin returns the deadline of an operation starts now by the timeout given by WithOperationTimeout.
+// It returns zero time, if there is no timeout, and ErrTimeout, if the circuit is open.
+func (ce *Cache) opBegin() (deadline time.Time, err error) {
+	if ce.opTimeout <= 0 {
+		return
+	}
+	now := time.Now()
+	if now.UnixNano() < atomic.LoadInt64(&ce.breakUntil) {
+		err = ErrTimeout
+		return
+	}
+	deadline = now.Add(ce.opTimeout)
+	return
+}
+
+// opEnd opens the circuit for the operation timeout, if the operation timed out.
+func (ce *Cache) opEnd(err error) {
+	if err == ErrTimeout {
+		atomic.StoreInt64(&ce.breakUntil, time.Now().Add(ce.opTimeout).UnixNano())
+	}
+}
+
+// GetOrSetErr is like GetOrSet, but it honors WithOperationTimeout and returns ErrTimeout without changing the cache.
+func (ce *Cache) GetOrSetErr(key string, newVal interface{}) (oldVal interface{}, found bool, err error) {
+	deadline, err := ce.opBegin()
+	if err != nil {
+		return
+	}
+	oldVal, found, err = ce.getOrSet(key, newVal, deadline)
+	ce.opEnd(err)
+	return
+}
+
+// GetAndSetErr is like GetAndSet, but it honors WithOperationTimeout and returns ErrTimeout without changing the cache.
+func (ce *Cache) GetAndSetErr(key string, f func(interface{}) interface{}) (newVal interface{}, err error) {
+	deadline, err := ce.opBegin()
+	if err != nil {
+		return
+	}
+	newVal, err = ce.getAndSet(key, f, deadline)
+	ce.opEnd(err)
+	return
+}
+
+// Sync waits until all writes enqueued before the call are committed to the tree.
+// It honors WithOperationTimeout and returns ErrTimeout.
+func (ce *Cache) Sync() (err error) {
+	deadline, err := ce.opBegin()
+	if err != nil {
+		return
+	}
+	err = ce.sync(deadline)
+	ce.opEnd(err)
+	return
+}
+
+func (ce *Cache) sync(deadline time.Time) (err error) {
+	if !ce.lockQueue(deadline) {
+		return ErrTimeout
+	}
+	target := ce.seq
+	if ce.cm != nil {
+		target++
+	}
+	if len(ce.qu) > 0 {
+		target++
+	}
+	ce.quMu.Unlock()
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		tm := time.NewTimer(time.Until(deadline))
+		defer tm.Stop()
+		timeout = tm.C
+	}
+	for {
+		ce.quMu.RLock()
+		seq, seqCh := ce.seq, ce.seqCh
+		ce.quMu.RUnlock()
+		if seq >= target {
+			return
+		}
+		ce.notify()
+		select {
+		case <-seqCh:
+		case <-timeout:
+			return ErrTimeout
+		case <-ce.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOperationTimeout(t *testing.T) {
+	ce := NewCache(WithOperationTimeout(50 * time.Millisecond))
+	defer ce.Close()
+	ce.quMu.Lock()
+	start := time.Now()
+	if _, _, err := ce.GetOrSetErr("k", 1); err != ErrTimeout {
+		t.Fatalf("got %v, want ErrTimeout", err)
+	}
+	if d := time.Since(start); d < 50*time.Millisecond {
+		t.Fatalf("gave up after %v", d)
+	}
+	ce.quMu.Unlock()
+
+	// the circuit is open, so it fails fast even though the lock is free.
+	start = time.Now()
+	if _, _, err := ce.GetOrSetErr("k", 1); err != ErrTimeout {
+		t.Fatalf("got %v, want ErrTimeout", err)
+	}
+	if d := time.Since(start); d > 25*time.Millisecond {
+		t.Fatalf("open circuit waited %v", d)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if _, found, err := ce.GetOrSetErr("k", 1); err != nil || found {
+		t.Fatalf("got found %v, err %v after the circuit closed", found, err)
+	}
+	if err := ce.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if val := ce.Get("k"); val != 1 {
+		t.Fatalf("got %v, want 1", val)
+	}
+}
@@ -0,0 +1,48 @@
+package cache
+
+var (
+	// DefaultTransactionRetries is default count of retries of Transaction on conflicts.
+	DefaultTransactionRetries = 8
+)
+
+// Transaction reads the value of given key, calls fn without holding any lock, and writes newVal only if the key
+// hasn't been written since the read and fn returns commit true. It returns whether newVal was written.
+// Writes are detected by a sequence given to every enqueued write, so touching the expiry is a write too.
+// If the key was written meanwhile, fn is called again with the new value, up to count of retries given by
+// WithTransactionRetries, DefaultTransactionRetries by default. So fn may be called many times, and it must be pure.
+// If newVal is nil, the key is deleted. The expiry of the entry is kept like GetAndSet.
+// If the key is reserved, it returns false without calling fn. If the missing key is rejected by WithMaxNamespaces,
+// it returns false and the error is reported to the error handler.
+func (ce *Cache) Transaction(key string, fn func(current interface{}, exists bool) (newVal interface{}, commit bool)) (committed bool) {
+	for i := 0; i <= ce.txRetries; i++ {
+		ce.quMu.RLock()
+		im, exists := ce.present(key)
+		ce.quMu.RUnlock()
+		if isReservation(im.Val) {
+			return
+		}
+		newVal, commit := fn(im.Val, exists)
+		if !commit {
+			return
+		}
+		ce.quMu.Lock()
+		cur, ok := ce.present(key)
+		if ok != exists || (ok && cur.ws != im.ws) {
+			ce.quMu.Unlock()
+			continue
+		}
+		if !ok && newVal != nil {
+			if err := ce.checkNamespace(key); err != nil {
+				ce.quMu.Unlock()
+				ce.error(err)
+				return
+			}
+		}
+		ce.enqueue(ce.updated(key, cur, newVal))
+		ce.quMu.Unlock()
+		ce.notify()
+		committed = true
+		return
+	}
+	return
+}
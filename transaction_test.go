@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"testing"
+)
+
+func TestTransactionConflict(t *testing.T) {
+	ce := NewCache()
+	defer ce.Close()
+	ce.Set("k", 1)
+	calls := 0
+	ok := ce.Transaction("k", func(current interface{}, exists bool) (interface{}, bool) {
+		calls++
+		if calls == 1 {
+			// a concurrent write of the same value at the same moment must still be detected.
+			ce.quMu.Lock()
+			im, _ := ce.lookup("k")
+			ce.enqueue(im)
+			ce.quMu.Unlock()
+		}
+		return current.(int) + 1, true
+	})
+	if !ok || calls != 2 {
+		t.Fatalf("got committed %v after %d calls, want true after 2", ok, calls)
+	}
+	if val := ce.Get("k"); val != 2 {
+		t.Fatalf("got %v, want 2", val)
+	}
+}
+
+func TestTransactionRetries(t *testing.T) {
+	ce := NewCache(WithTransactionRetries(2))
+	defer ce.Close()
+	calls := 0
+	ok := ce.Transaction("k", func(current interface{}, exists bool) (interface{}, bool) {
+		calls++
+		ce.Set("k", calls)
+		return "lost", true
+	})
+	if ok || calls != 3 {
+		t.Fatalf("got committed %v after %d calls, want false after 3", ok, calls)
+	}
+}
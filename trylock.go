@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"time"
+)
+
+var (
+	// DefaultTryTimeout is default timeout of Try methods to acquire locks.
+	DefaultTryTimeout = time.Millisecond
+)
+
+// maxTryBackoff is the longest sleep between tries of lockUntil.
+const maxTryBackoff = 64 * time.Microsecond
+
+// lockUntil calls try until it acquires the lock or the deadline exceeds, and returns false if the deadline exceeded.
+// It sleeps between tries with exponential backoff up to maxTryBackoff, so it doesn't spin while waiting, and it never
+// leaves a pending lock behind when it gives up. An uncontended lock is acquired by the first try without a sleep.
+// try is TryLock or TryRLock, so it needs Go 1.18 or later.
+func lockUntil(deadline time.Time, try func() bool) bool {
+	backoff := time.Microsecond
+	for !try() {
+		d := time.Until(deadline)
+		if d <= 0 {
+			return false
+		}
+		if d > backoff {
+			d = backoff
+		}
+		time.Sleep(d)
+		if backoff < maxTryBackoff {
+			backoff *= 2
+		}
+	}
+	return true
+}
+
+// TryGetOrSet is like GetOrSet, but it gives up if the locks couldn't be acquired in the timeout given by
+// WithTryTimeout, DefaultTryTimeout by default. ok is false, if it gave up without reading or writing the cache.
+// A key rejected by WithMaxNamespaces is reported to the error handler like GetOrSet.
+func (ce *Cache) TryGetOrSet(key string, newVal interface{}) (val interface{}, found, ok bool) {
+	val, found, err := ce.getOrSet(key, newVal, time.Now().Add(ce.tryTimeout))
+	ok = err != ErrTimeout
+	if ok && err != nil {
+		ce.error(err)
+	}
+	return
+}
@@ -0,0 +1,42 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTryGetOrSet(t *testing.T) {
+	ce := NewCache(WithTryTimeout(10 * time.Millisecond))
+	defer ce.Close()
+	if val, found, ok := ce.TryGetOrSet("k", 1); !ok || found || val != 1 {
+		t.Fatalf("got %v, %v, %v", val, found, ok)
+	}
+	ce.Sync()
+	ce.trMu.Lock()
+	if _, _, ok := ce.TryGetOrSet("other", 1); ok {
+		t.Error("didn't give up while the tree is locked")
+	}
+	ce.trMu.Unlock()
+}
+
+func TestTryGetOrSetLeavesNoPendingLock(t *testing.T) {
+	ce := NewCache(WithTryTimeout(5 * time.Millisecond))
+	defer ce.Close()
+	ce.quMu.RLock()
+	if _, _, ok := ce.TryGetOrSet("k", 1); ok {
+		t.Fatal("didn't give up while the queue is locked")
+	}
+	// a writer waiting for quMu would block new readers.
+	done := make(chan struct{})
+	go func() {
+		ce.quMu.RLock()
+		ce.quMu.RUnlock()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("readers are blocked after TryGetOrSet gave up")
+	}
+	ce.quMu.RUnlock()
+}
@@ -0,0 +1,176 @@
+package cache
+
+import (
+	"container/heap"
+	"sync/atomic"
+	"time"
+)
+
+// expiry returns the expiry time of ttl from now in unix nanoseconds. It returns 0 for non-positive ttl.
+func expiry(ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+	return time.Now().Add(ttl).UnixNano()
+}
+
+// SetTTL sets the value of given key like Set, and the key expires after ttl. The key never expires, if ttl isn't positive.
+// Expired keys aren't visible to any method. The key is rejected by WithMaxNamespaces like Set.
+func (ce *Cache) SetTTL(key string, val interface{}, ttl time.Duration) {
+	im := ce.newItem(key, val)
+	im.exp = expiry(ttl)
+	ce.quMu.Lock()
+	if val != nil {
+		if err := ce.checkNamespace(key); err != nil {
+			ce.quMu.Unlock()
+			ce.error(err)
+			return
+		}
+	}
+	if im.exp > 0 {
+		atomic.StoreInt32(&ce.hidden, 1)
+	}
+	ce.enqueue(im)
+	ce.quMu.Unlock()
+	ce.notify()
+}
+
+// Touch resets the expiry of given key to ttl from now, and returns whether the key was exist.
+// The key never expires, if ttl isn't positive.
+func (ce *Cache) Touch(key string, ttl time.Duration) bool {
+	return ce.TouchIf(key, ttl, nil)
+}
+
+// TouchIf is like Touch, but it resets the expiry only if pred returns true for the current value.
+// pred is called atomically with the reset. It returns whether the expiry was reset.
+func (ce *Cache) TouchIf(key string, ttl time.Duration, pred func(current interface{}) bool) (ok bool) {
+	_, ok = ce.touch(key, ttl, pred)
+	return
+}
+
+// GetAndTouch returns the value of given key, and resets its expiry to ttl from now atomically.
+// It returns false, if the key wasn't exist or has expired. The read counts as a hit like Get.
+func (ce *Cache) GetAndTouch(key string, ttl time.Duration) (val interface{}, ok bool) {
+	im, ok := ce.touch(key, ttl, nil)
+	if ok {
+		val = im.Val
+		im.hit()
+	}
+	return
+}
+
+// touch resets the expiry of given key, if pred is nil or returns true for the current value. It returns the touched item.
+func (ce *Cache) touch(key string, ttl time.Duration, pred func(current interface{}) bool) (im item, ok bool) {
+	ce.quMu.Lock()
+	im, ok = ce.lookup(key)
+	if !ok || (pred != nil && !pred(im.Val)) {
+		ce.quMu.Unlock()
+		ok = false
+		return
+	}
+	im.exp = expiry(ttl)
+	if im.exp > 0 {
+		atomic.StoreInt32(&ce.hidden, 1)
+	}
+	ce.enqueue(im)
+	ce.quMu.Unlock()
+	ce.notify()
+	return
+}
+
+type expiryNode struct {
+	exp int64
+	key string
+}
+
+// expiryHeap is a min-heap of expiry times of keys. Nodes may be stale, if the entry was overwritten or touched.
+type expiryHeap []expiryNode
+
+func (h expiryHeap) Len() int            { return len(h) }
+func (h expiryHeap) Less(i, j int) bool  { return h[i].exp < h[j].exp }
+func (h expiryHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *expiryHeap) Push(x interface{}) { *h = append(*h, x.(expiryNode)) }
+func (h *expiryHeap) Pop() (x interface{}) {
+	old := *h
+	x = old[len(old)-1]
+	*h = old[:len(old)-1]
+	return
+}
+
+// scheduleExpiry pushes the expiry of the item into the heap, and wakes up the sweeper if it is the soonest.
+func (ce *Cache) scheduleExpiry(im item) {
+	ce.expMu.Lock()
+	heap.Push(&ce.expHeap, expiryNode{exp: im.exp, key: im.Key})
+	soonest := ce.expHeap[0].exp == im.exp
+	ce.expMu.Unlock()
+	if soonest {
+		select {
+		case ce.expWake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// NextExpiry returns the soonest expiry time of the entries. ok is false, if no entry has an expiry.
+// The time may belong to an entry overwritten or touched after it was set.
+func (ce *Cache) NextExpiry() (t time.Time, ok bool) {
+	ce.expMu.Lock()
+	if len(ce.expHeap) > 0 {
+		t, ok = time.Unix(0, ce.expHeap[0].exp), true
+	}
+	ce.expMu.Unlock()
+	return
+}
+
+// expiryWorker deletes expired entries from the tree, sleeping until the soonest expiry.
+func (ce *Cache) expiryWorker() {
+	tm := time.NewTimer(time.Hour)
+	defer tm.Stop()
+	for {
+		ce.expMu.Lock()
+		var next int64
+		if len(ce.expHeap) > 0 {
+			next = ce.expHeap[0].exp
+		}
+		ce.expMu.Unlock()
+		if !tm.Stop() {
+			select {
+			case <-tm.C:
+			default:
+			}
+		}
+		if next > 0 {
+			tm.Reset(time.Until(time.Unix(0, next)))
+		} else {
+			tm.Reset(time.Hour)
+		}
+		select {
+		case <-ce.done:
+			return
+		case <-ce.expWake:
+		case <-tm.C:
+		}
+		ce.sweep()
+	}
+}
+
+// sweep pops due nodes from the heap, and deletes their entries if they are still the same and expired.
+func (ce *Cache) sweep() {
+	now := time.Now().UnixNano()
+	for {
+		ce.expMu.Lock()
+		if len(ce.expHeap) == 0 || ce.expHeap[0].exp > now {
+			ce.expMu.Unlock()
+			break
+		}
+		n := heap.Pop(&ce.expHeap).(expiryNode)
+		ce.expMu.Unlock()
+		ce.trMu.Lock()
+		if r := ce.tr.Get(ce.probe(n.key)); r != nil && r.(item).exp == n.exp && !r.(item).pin {
+			ce.tr.Delete(r)
+			ce.removed(r.(item))
+			ce.publish(Event{Key: n.key})
+		}
+		ce.trMu.Unlock()
+	}
+}
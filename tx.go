@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"github.com/google/btree"
+)
+
+// Tx is a transaction of Update. It is valid only in the function given to Update.
+type Tx struct {
+	ce *Cache
+	n  int
+	tr *btree.BTree
+}
+
+// Get returns the value of given key including writes of the transaction. It returns nil, if the key wasn't exist.
+func (tx *Tx) Get(key string) (val interface{}) {
+	if im, ok := tx.ce.lookup(key); ok {
+		val = im.Val
+	}
+	return
+}
+
+// Set sets the value of given key when the transaction ends. It returns ErrTooManyNamespaces without writing,
+// if the key is rejected by WithMaxNamespaces. Namespaces of earlier writes of the transaction are counted too.
+func (tx *Tx) Set(key string, val interface{}) (err error) {
+	if val != nil {
+		if err = tx.ce.checkNamespace(key); err != nil {
+			return
+		}
+	}
+	tx.write(tx.ce.newItem(key, val))
+	return
+}
+
+// Del deletes given key when the transaction ends.
+func (tx *Tx) Del(key string) {
+	tx.write(tx.ce.newItem(key, nil))
+}
+
+// write enqueues the item at once. Nobody sees it until the transaction ends, because the queue is locked.
+func (tx *Tx) write(im item) {
+	tx.ce.enqueue(im)
+	tx.n++
+	if tx.tr == nil {
+		return
+	}
+	if im.Val != nil {
+		tx.tr.ReplaceOrInsert(im)
+	} else {
+		tx.tr.Delete(im)
+	}
+}
+
+// Range calls fn for every entry in [start, end) in ascending key order including writes of the transaction,
+// until fn returns false. If end is empty, the range has no upper bound. Entries don't change by other writers
+// while the transaction runs, so every Range sees the same state except writes of the transaction.
+func (tx *Tx) Range(start, end string, fn func(key string, val interface{}) bool) {
+	if tx.tr == nil {
+		tx.tr = tx.ce.snapshotLocked()
+	}
+	tx.ce.ascendRange(tx.tr, start, end, func(im item) bool {
+		if !im.visible() {
+			return true
+		}
+		return fn(im.Key, im.Val)
+	})
+}
+
+// Update calls fn with a transaction, and commits writes of the transaction atomically after fn returns.
+// Reads and writes of the transaction are consistent, because the queue is locked while fn runs; the tree lock
+// isn't held for the whole transaction, it is taken only briefly by reads of tx. So transactions block all reads and
+// writes of the cache, including Get, and they should be short. fn mustn't use the cache except by tx.
+func (ce *Cache) Update(fn func(tx *Tx)) {
+	ce.quMu.Lock()
+	tx := &Tx{ce: ce}
+	fn(tx)
+	ce.quMu.Unlock()
+	if tx.n > 0 {
+		ce.notify()
+	}
+}
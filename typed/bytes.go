@@ -0,0 +1,31 @@
+package typed
+
+import (
+	cache "github.com/orkunkaraduman/go-cache"
+)
+
+// BytesCache is a type-safe wrapper of cache.Cache for []byte values.
+// Slices are stored and returned without copying, so they mustn't be modified.
+type BytesCache struct {
+	*cache.Cache
+}
+
+// NewBytesCache returns a new BytesCache wraps a new cache.Cache given options.
+func NewBytesCache(opts ...cache.Option) BytesCache {
+	return BytesCache{Cache: cache.NewCache(opts...)}
+}
+
+// Get returns the value of given key. ok is false, if the key wasn't exist.
+func (c BytesCache) Get(key string) (val []byte, ok bool) {
+	val, ok = c.Cache.Get(key).([]byte)
+	return
+}
+
+// Set sets the value of given key. It deletes the key, if val is nil.
+func (c BytesCache) Set(key string, val []byte) {
+	if val == nil {
+		c.Cache.Del(key)
+		return
+	}
+	c.Cache.Set(key, val)
+}
@@ -0,0 +1,49 @@
+package typed
+
+import (
+	cache "github.com/orkunkaraduman/go-cache"
+)
+
+// IntCache is a type-safe wrapper of cache.Cache for int values.
+type IntCache struct {
+	*cache.Cache
+}
+
+// NewIntCache returns a new IntCache wraps a new cache.Cache given options.
+func NewIntCache(opts ...cache.Option) IntCache {
+	return IntCache{Cache: cache.NewCache(opts...)}
+}
+
+// Get returns the value of given key. ok is false, if the key wasn't exist.
+func (c IntCache) Get(key string) (val int, ok bool) {
+	val, ok = c.Cache.Get(key).(int)
+	return
+}
+
+// Set sets the value of given key.
+func (c IntCache) Set(key string, val int) {
+	c.Cache.Set(key, val)
+}
+
+// Inc increases the value of given key by delta, and returns new value. If the key wasn't exist, it is set to delta.
+// It returns 0 without writing, if the value isn't int, the key is reserved or the write is rejected.
+// It doesn't panic for non-numeric values even if WithStrictNumeric option is given.
+func (c IntCache) Inc(key string, delta int) int {
+	for {
+		val, _ := c.Cache.IncErr(key, int64(delta))
+		switch val := val.(type) {
+		case int:
+			return val
+		case nil:
+		default:
+			return 0
+		}
+		oldVal, found, err := c.Cache.GetOrSetErr(key, delta)
+		if err != nil || (found && oldVal == nil) {
+			return 0
+		}
+		if !found {
+			return delta
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package typed
+
+import (
+	"sync"
+	"testing"
+
+	cache "github.com/orkunkaraduman/go-cache"
+)
+
+func TestIntCacheIncMissing(t *testing.T) {
+	c := NewIntCache()
+	defer c.Close()
+	if val := c.Inc("k", 3); val != 3 {
+		t.Errorf("got %d, want 3", val)
+	}
+	if val := c.Inc("k", 2); val != 5 {
+		t.Errorf("got %d, want 5", val)
+	}
+	if val, ok := c.Get("k"); !ok || val != 5 {
+		t.Errorf("Get: got %d, %v", val, ok)
+	}
+}
+
+func TestIntCacheIncConcurrent(t *testing.T) {
+	c := NewIntCache()
+	defer c.Close()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.Inc("k", 1)
+			}
+		}()
+	}
+	wg.Wait()
+	if val, _ := c.Get("k"); val != 800 {
+		t.Errorf("got %d, want 800", val)
+	}
+}
+
+func TestIntCacheIncReserved(t *testing.T) {
+	c := NewIntCache()
+	defer c.Close()
+	if _, ok := c.Reserve("k"); !ok {
+		t.Fatal("couldn't reserve")
+	}
+	if val := c.Inc("k", 1); val != 0 {
+		t.Errorf("got %d, want 0", val)
+	}
+}
+
+func TestIntCacheIncRejected(t *testing.T) {
+	c := NewIntCache(cache.WithMaxNamespaces(1, ":"))
+	defer c.Close()
+	c.Set("a:1", 1)
+	if val := c.Inc("b:1", 1); val != 0 {
+		t.Errorf("got %d, want 0", val)
+	}
+	if _, ok := c.Get("b:1"); ok {
+		t.Error("rejected key was written")
+	}
+}
+
+func TestIntCacheIncStrictNumeric(t *testing.T) {
+	c := NewIntCache(cache.WithStrictNumeric())
+	defer c.Close()
+	c.Cache.Set("k", "x")
+	if val := c.Inc("k", 1); val != 0 {
+		t.Errorf("got %d, want 0", val)
+	}
+	if val := c.Inc("n", 2); val != 2 {
+		t.Errorf("got %d, want 2", val)
+	}
+}
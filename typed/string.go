@@ -0,0 +1,26 @@
+package typed
+
+import (
+	cache "github.com/orkunkaraduman/go-cache"
+)
+
+// StringCache is a type-safe wrapper of cache.Cache for string values.
+type StringCache struct {
+	*cache.Cache
+}
+
+// NewStringCache returns a new StringCache wraps a new cache.Cache given options.
+func NewStringCache(opts ...cache.Option) StringCache {
+	return StringCache{Cache: cache.NewCache(opts...)}
+}
+
+// Get returns the value of given key. ok is false, if the key wasn't exist.
+func (c StringCache) Get(key string) (val string, ok bool) {
+	val, ok = c.Cache.Get(key).(string)
+	return
+}
+
+// Set sets the value of given key.
+func (c StringCache) Set(key string, val string) {
+	c.Cache.Set(key, val)
+}
@@ -0,0 +1,6 @@
+/*
+Package typed offers type-safe wrappers of cache.Cache for common value types.
+The wrappers do type assertions internally. Mixing value types on a wrapped cache is undefined,
+values of other types are treated as not found.
+*/
+package typed
@@ -0,0 +1,43 @@
+package typed
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMixedTypes(t *testing.T) {
+	ic := NewIntCache()
+	defer ic.Close()
+	sc := StringCache{Cache: ic.Cache}
+	bc := BytesCache{Cache: ic.Cache}
+	ic.Set("i", 1)
+	sc.Set("s", "x")
+	bc.Set("b", []byte("y"))
+	ic.Cache.Set("i64", int64(2))
+
+	if val, ok := ic.Get("s"); ok || val != 0 {
+		t.Errorf("IntCache.Get of string: got %d, %v", val, ok)
+	}
+	if val, ok := sc.Get("i"); ok || val != "" {
+		t.Errorf("StringCache.Get of int: got %q, %v", val, ok)
+	}
+	if val, ok := bc.Get("s"); ok || val != nil {
+		t.Errorf("BytesCache.Get of string: got %q, %v", val, ok)
+	}
+	if val := ic.Inc("s", 1); val != 0 {
+		t.Errorf("IntCache.Inc of string: got %d", val)
+	}
+	if val := ic.Inc("i64", 1); val != 0 {
+		t.Errorf("IntCache.Inc of int64: got %d", val)
+	}
+	if val, _ := sc.Get("s"); val != "x" {
+		t.Errorf("Inc changed the string: got %q", val)
+	}
+	if val, ok := bc.Get("b"); !ok || !bytes.Equal(val, []byte("y")) {
+		t.Errorf("BytesCache.Get: got %q, %v", val, ok)
+	}
+	bc.Set("b", nil)
+	if _, ok := bc.Get("b"); ok {
+		t.Error("BytesCache.Set of nil didn't delete")
+	}
+}
@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"strings"
+	"sync"
+)
+
+// Event is a committed change of the cache. Val is nil, if the key was deleted or its value isn't visible
+// to reads, like a reservation of Reserve or a nil result of Memoize.
+// Gap is true only for the sentinel event of Tail, it means some events were lost.
+type Event struct {
+	Key string
+	Val interface{}
+	Gap bool
+}
+
+type watcher struct {
+	prefix string
+	ch     chan Event
+}
+
+const watchBufferSize = 1 << 10
+
+// Watch returns a channel delivers every committed change and a function to stop watching.
+// Events are delivered by the queue worker without blocking, so they are dropped if the channel is full.
+func (ce *Cache) Watch() (<-chan Event, func()) {
+	return ce.WatchPrefix("")
+}
+
+// WatchPrefix is like Watch, but it delivers only changes of keys have given prefix.
+func (ce *Cache) WatchPrefix(prefix string) (<-chan Event, func()) {
+	w := &watcher{
+		prefix: prefix,
+		ch:     make(chan Event, watchBufferSize),
+	}
+	ce.wMu.Lock()
+	if ce.watchers == nil {
+		ce.watchers = make(map[*watcher]struct{})
+	}
+	ce.watchers[w] = struct{}{}
+	ce.wMu.Unlock()
+	var once sync.Once
+	return w.ch, func() {
+		once.Do(func() {
+			ce.wMu.Lock()
+			delete(ce.watchers, w)
+			ce.wMu.Unlock()
+			close(w.ch)
+		})
+	}
+}
+
+// publish delivers the event to the watchers are interested in. It must be called while trMu is locked.
+func (ce *Cache) publish(e Event) {
+	ce.wMu.RLock()
+	for w := range ce.watchers {
+		if !strings.HasPrefix(e.Key, w.prefix) {
+			continue
+		}
+		select {
+		case w.ch <- e:
+		default:
+		}
+	}
+	for t := range ce.tailers {
+		t.push(e)
+	}
+	ce.wMu.RUnlock()
+}
@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/google/btree"
+)
+
+// LastWrite returns the write time of the most recently written entry committed by the queue worker.
+// It returns zero time, if nothing was committed. It is O(1).
+func (ce *Cache) LastWrite() time.Time {
+	wt := atomic.LoadInt64(&ce.lastWrite)
+	if wt == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, wt)
+}
+
+// OldestWrite returns the write time of the oldest written committed entry. ok is false, if the cache is empty.
+// Entries aren't visible to reads, like expired entries and reservations, aren't counted.
+// It scans all entries holding the tree read lock, so it is O(n) and meant for periodic monitoring.
+func (ce *Cache) OldestWrite() (t time.Time, ok bool) {
+	var oldest int64
+	ce.trMu.RLock()
+	ce.tr.Ascend(func(i btree.Item) bool {
+		im := i.(item)
+		if !im.visible() {
+			return true
+		}
+		if !ok || im.wt < oldest {
+			oldest, ok = im.wt, true
+		}
+		return true
+	})
+	ce.trMu.RUnlock()
+	if ok {
+		t = time.Unix(0, oldest)
+	}
+	return
+}
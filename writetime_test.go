@@ -0,0 +1,25 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOldestWriteSkipsInvisible(t *testing.T) {
+	ce := NewCache()
+	defer ce.Close()
+	ce.Reserve("r")
+	ce.Memoize("m", func() interface{} { return nil })
+	ce.SetTTL("e", 1, time.Millisecond)
+	ce.Sync()
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := ce.OldestWrite(); ok {
+		t.Error("invisible entries are counted")
+	}
+	start := time.Now()
+	ce.Set("k", 1)
+	ce.Sync()
+	if wt, ok := ce.OldestWrite(); !ok || wt.Before(start) {
+		t.Errorf("got %v, %v", wt, ok)
+	}
+}